@@ -0,0 +1,123 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package crypto
+
+import (
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/ext"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// PlainKey is the symmetric key for broadcast message,
+// which will do nothing when en/decoding message data
+//
+// Broadcast message content is just serialized to JsON (not encrypted),
+// so everyone (including the stations) can read it.
+//
+//	keyInfo format: {
+//	    "algorithm" : "PLAIN",
+//	    "data"      : ""       // empty data
+//	}
+type PlainKey struct {
+	//SymmetricKey
+	*Dictionary
+}
+
+func NewPlainKey(dict StringKeyMap) *PlainKey {
+	if dict == nil {
+		dict = StringKeyMap{
+			"algorithm": PLAIN,
+		}
+	}
+	return &PlainKey{
+		Dictionary: NewDictionary(dict),
+	}
+}
+
+var sharedPlainKey = NewPlainKey(nil)
+
+// GetPlainKey returns the shared PLAIN key
+func GetPlainKey() SymmetricKey {
+	return sharedPlainKey
+}
+
+// IsPlainKey checks whether the symmetric key is for broadcast message
+func IsPlainKey(key CryptographyKey) bool {
+	return key != nil && key.Algorithm() == PLAIN
+}
+
+// Override
+func (key *PlainKey) Algorithm() string {
+	return PLAIN
+}
+
+// Override
+func (key *PlainKey) Data() TransportableData {
+	return NewPlainData("", []byte{})
+}
+
+// Override
+func (key *PlainKey) Encrypt(plaintext []byte, _ StringKeyMap) []byte {
+	return plaintext
+}
+
+// Override
+func (key *PlainKey) Decrypt(ciphertext []byte, _ StringKeyMap) []byte {
+	return ciphertext
+}
+
+// Override
+func (key *PlainKey) MatchEncryptKey(pKey EncryptKey) bool {
+	return MatchSymmetricKeys(pKey, key)
+}
+
+/**
+ *  PLAIN Key Factory
+ */
+type PlainKeyFactory struct {
+	//SymmetricKeyFactory
+}
+
+// Override
+func (*PlainKeyFactory) GenerateSymmetricKey() SymmetricKey {
+	return GetPlainKey()
+}
+
+// Override
+func (*PlainKeyFactory) ParseSymmetricKey(key StringKeyMap) SymmetricKey {
+	if ConvertString(key["algorithm"], "") != PLAIN {
+		return nil
+	}
+	return GetPlainKey()
+}
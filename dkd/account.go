@@ -0,0 +1,193 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/core-go/mkm"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Register Command
+ */
+
+type BaseRegisterCommand struct {
+	//RegisterCommand
+	*BaseHistoryCommand
+}
+
+func NewBaseRegisterCommand(dict StringKeyMap, did ID, meta Meta, visa Visa) *BaseRegisterCommand {
+	content := &BaseRegisterCommand{
+		BaseHistoryCommand: NewBaseHistoryCommand(dict, REGISTER),
+	}
+	if dict == nil {
+		content.SetStringer("did", did)
+		content.SetMapper("meta", meta)
+		content.SetMapper("visa", visa)
+	}
+	return content
+}
+
+// Override
+func (content *BaseRegisterCommand) ID() ID {
+	return ParseID(content.Get("did"))
+}
+
+// Override
+func (content *BaseRegisterCommand) Meta() Meta {
+	return ParseMeta(content.Get("meta"))
+}
+
+// Override
+func (content *BaseRegisterCommand) Visa() Visa {
+	doc := ParseDocument(content.Get("visa"))
+	if visa, ok := doc.(Visa); ok {
+		return visa
+	}
+	return nil
+}
+
+/**
+ *  Suicide Command
+ */
+
+type BaseSuicideCommand struct {
+	//SuicideCommand
+	*BaseHistoryCommand
+}
+
+func NewBaseSuicideCommand(dict StringKeyMap, did ID, farewell string, successor ID) *BaseSuicideCommand {
+	content := &BaseSuicideCommand{
+		BaseHistoryCommand: NewBaseHistoryCommand(dict, SUICIDE),
+	}
+	if dict == nil {
+		content.SetStringer("did", did)
+		content.Set("farewell", farewell)
+		content.SetStringer("successor", successor)
+	}
+	return content
+}
+
+// Override
+func (content *BaseSuicideCommand) ID() ID {
+	return ParseID(content.Get("did"))
+}
+
+// Override
+func (content *BaseSuicideCommand) Farewell() string {
+	return content.GetString("farewell", "")
+}
+
+// Override
+func (content *BaseSuicideCommand) Successor() ID {
+	return ParseID(content.Get("successor"))
+}
+
+// Override
+func (content *BaseSuicideCommand) Signature() TransportableData {
+	return ParseTransportableData(content.Get("signature"))
+}
+
+// Override
+func (content *BaseSuicideCommand) SetSignature(signature TransportableData) {
+	if signature == nil {
+		content.Remove("signature")
+	} else {
+		content.Set("signature", signature.Serialize())
+	}
+}
+
+// Override
+func (content *BaseSuicideCommand) SignData() []byte {
+	info := StringKeyMap{
+		"did":      content.Get("did"),
+		"time":     content.Get("time"),
+		"farewell": content.Get("farewell"),
+	}
+	if successor := content.Get("successor"); successor != nil {
+		info["successor"] = successor
+	}
+	return UTF8Encode(CanonicalJSONEncodeMap(info))
+}
+
+/**
+ *  Account Verification
+ */
+
+// VerifyRegisterCommand checks the meta with account ID,
+// and the visa signed by the meta key
+func VerifyRegisterCommand(content RegisterCommand) bool {
+	did := content.ID()
+	meta := content.Meta()
+	if meta == nil || !MetaMatchID(meta, did) {
+		return false
+	}
+	visa := content.Visa()
+	if visa == nil {
+		return false
+	}
+	owner := ParseID(visa.Get("did"))
+	if owner == nil || !owner.Equal(did) {
+		return false
+	}
+	return visa.Verify(meta.PublicKey())
+}
+
+// SignSuicideCommand signs the farewell with the account private key,
+// which must match the account meta
+func SignSuicideCommand(content SuicideCommand, meta Meta, sKey SignKey) bool {
+	if !MetaMatchID(meta, content.ID()) || !MetaMatchSignKey(meta, sKey) {
+		return false
+	}
+	signature := sKey.Sign(content.SignData())
+	if len(signature) == 0 {
+		return false
+	}
+	content.SetSignature(NewBase64DataWithBytes(signature))
+	return true
+}
+
+// VerifySuicideCommand checks the signature of farewell with the account meta
+func VerifySuicideCommand(content SuicideCommand, meta Meta) bool {
+	if !MetaMatchID(meta, content.ID()) {
+		return false
+	}
+	signature := content.Signature()
+	if signature == nil {
+		return false
+	}
+	return meta.PublicKey().Verify(content.SignData(), signature.Bytes())
+}
@@ -0,0 +1,91 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Application Customized Content
+ */
+
+type AppCustomizedContent struct {
+	//AppContent, CustomizedContent
+	*BaseContent
+}
+
+func NewAppCustomizedContent(dict StringKeyMap, msgType MessageType, app, mod, act string) *AppCustomizedContent {
+	if dict != nil {
+		// init app customized content with map
+		return &AppCustomizedContent{
+			BaseContent: NewBaseContent(dict, ""),
+		}
+	}
+	// new app customized content
+	if msgType == "" {
+		msgType = ContentType.CUSTOMIZED
+	}
+	content := &AppCustomizedContent{
+		BaseContent: NewBaseContent(nil, msgType),
+	}
+	content.Set("app", app)
+	content.Set("mod", mod)
+	content.Set("act", act)
+	return content
+}
+
+// Override
+func (content *AppCustomizedContent) Application() string {
+	return content.GetString("app", "")
+}
+
+// Override
+func (content *AppCustomizedContent) Module() string {
+	return content.GetString("mod", "")
+}
+
+// Override
+func (content *AppCustomizedContent) Action() string {
+	return content.GetString("act", "")
+}
+
+// Override
+func (content *AppCustomizedContent) Payload() any {
+	return content.Get("payload")
+}
+
+// Override
+func (content *AppCustomizedContent) SetPayload(payload any) {
+	content.Set("payload", payload)
+}
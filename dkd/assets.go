@@ -31,6 +31,9 @@
 package dkd
 
 import (
+	"fmt"
+	"sort"
+
 	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/protocol"
@@ -85,7 +88,7 @@ func (content *BaseMoneyContent) SetAmount(amount float64) {
  */
 
 type TransferMoneyContent struct {
-	//TransferContent
+	//TransferContent, ClaimFulfillment
 	*BaseMoneyContent
 }
 
@@ -116,3 +119,358 @@ func (content *TransferMoneyContent) Remittee() ID {
 func (content *TransferMoneyContent) SetRemittee(receiver ID) {
 	content.SetStringer("remittee", receiver)
 }
+
+// Override
+func (content *TransferMoneyContent) ClaimSerialNumber() SerialNumberType {
+	claim := FetchMap(content.Get("claim"))
+	if claim == nil {
+		return 0
+	}
+	return ConvertUInt64(claim["sn"], 0)
+}
+
+// Override
+func (content *TransferMoneyContent) SetClaimSerialNumber(sn SerialNumberType) {
+	if sn == 0 {
+		content.Remove("claim")
+	} else {
+		content.Set("claim", StringKeyMap{
+			"sn": sn,
+		})
+	}
+}
+
+/**
+ *  Claim for Payment Content
+ */
+
+type BaseClaimPaymentContent struct {
+	//ClaimPaymentContent
+	*BaseMoneyContent
+
+	invoice TransportableFile
+}
+
+func NewBaseClaimPaymentContent(dict StringKeyMap, currency string, amount float64, payee ID) *BaseClaimPaymentContent {
+	if dict != nil {
+		// init claim content with map
+		return &BaseClaimPaymentContent{
+			BaseMoneyContent: NewBaseMoneyContent(dict, "", "", 0),
+		}
+	}
+	// new claim content
+	content := &BaseClaimPaymentContent{
+		BaseMoneyContent: NewBaseMoneyContent(nil, ContentType.CLAIM_PAYMENT, currency, amount),
+	}
+	content.SetStringer("payee", payee)
+	return content
+}
+
+// Override
+func (content *BaseClaimPaymentContent) Payee() ID {
+	payee := content.Get("payee")
+	return ParseID(payee)
+}
+
+// Override
+func (content *BaseClaimPaymentContent) SetPayee(payee ID) {
+	content.SetStringer("payee", payee)
+}
+
+// Override
+func (content *BaseClaimPaymentContent) Memo() string {
+	return content.GetString("memo", "")
+}
+
+// Override
+func (content *BaseClaimPaymentContent) SetMemo(memo string) {
+	content.Set("memo", memo)
+}
+
+// Override
+func (content *BaseClaimPaymentContent) DueDate() Time {
+	return content.GetTime("due", nil)
+}
+
+// Override
+func (content *BaseClaimPaymentContent) SetDueDate(when Time) {
+	content.SetTime("due", when)
+}
+
+// Override
+func (content *BaseClaimPaymentContent) Invoice() TransportableFile {
+	invoice := content.invoice
+	if invoice == nil {
+		pnf := content.Get("invoice")
+		invoice = ParseTransportableFile(pnf)
+		content.invoice = invoice
+	}
+	return invoice
+}
+
+// Override
+func (content *BaseClaimPaymentContent) SetInvoice(invoice TransportableFile) {
+	if invoice == nil || invoice.IsEmpty() {
+		content.Remove("invoice")
+	} else {
+		content.Set("invoice", invoice.Serialize())
+	}
+	content.invoice = invoice
+}
+
+/**
+ *  Lucky Money Content
+ */
+
+type BaseLuckyMoneyContent struct {
+	//LuckyMoneyContent
+	*BaseMoneyContent
+}
+
+func NewBaseLuckyMoneyContent(dict StringKeyMap, currency string, amount float64, count int, mode string, text string) *BaseLuckyMoneyContent {
+	if dict != nil {
+		// init lucky money content with map
+		return &BaseLuckyMoneyContent{
+			BaseMoneyContent: NewBaseMoneyContent(dict, "", "", 0),
+		}
+	}
+	// new lucky money content
+	if mode == "" {
+		mode = LUCKY_MONEY_RANDOM
+	}
+	content := &BaseLuckyMoneyContent{
+		BaseMoneyContent: NewBaseMoneyContent(nil, ContentType.LUCK_MONEY, currency, amount),
+	}
+	content.Set("count", count)
+	content.Set("mode", mode)
+	if text != "" {
+		content.Set("text", text)
+	}
+	return content
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) Count() int {
+	return content.GetInt("count", 0)
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) Mode() string {
+	return content.GetString("mode", LUCKY_MONEY_RANDOM)
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) Text() string {
+	return content.GetString("text", "")
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) Expires() Time {
+	return content.GetTime("expires", nil)
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) SetExpires(when Time) {
+	content.SetTime("expires", when)
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) Claims() []LuckyMoneyClaim {
+	array := FetchList(content.Get("claims"))
+	claims := make([]LuckyMoneyClaim, 0, len(array))
+	for _, item := range array {
+		info := FetchMap(item)
+		if info != nil {
+			claims = append(claims, NewBaseLuckyMoneyClaim(info, nil, 0, nil))
+		}
+	}
+	return claims
+}
+
+// Override
+func (content *BaseLuckyMoneyContent) AddClaim(claim LuckyMoneyClaim) error {
+	member := ConvertString(claim.Get("ID"), "")
+	amount := claim.Amount()
+	if member == "" || amount <= 0 {
+		return fmt.Errorf("lucky money claim error: %v", claim.Map())
+	}
+	array := FetchList(content.Get("claims"))
+	if len(array) >= content.Count() {
+		return ErrClaimsExhausted
+	}
+	total := amount
+	for _, item := range array {
+		info := FetchMap(item)
+		if ConvertString(info["ID"], "") == member {
+			return ErrClaimDuplicated
+		}
+		total += ConvertFloat64(info["amount"], 0)
+	}
+	// tolerate the rounding error of float numbers
+	if total > content.Amount()+1e-9 {
+		return ErrClaimOverdrawn
+	}
+	claims := make([]any, 0, len(array)+1)
+	claims = append(claims, array...)
+	claims = append(claims, claim.Map())
+	content.Set("claims", claims)
+	return nil
+}
+
+/**
+ *  Lucky Money Claim
+ */
+
+type BaseLuckyMoneyClaim struct {
+	//LuckyMoneyClaim
+	*Dictionary
+}
+
+func NewBaseLuckyMoneyClaim(dict StringKeyMap, receiver ID, amount float64, when Time) *BaseLuckyMoneyClaim {
+	if dict == nil {
+		if TimeIsNil(when) {
+			when = TimeNow()
+		}
+		dict = StringKeyMap{
+			"ID":     receiver.String(),
+			"amount": amount,
+			"time":   TimeToFloat64(when),
+		}
+	}
+	return &BaseLuckyMoneyClaim{
+		Dictionary: NewDictionary(dict),
+	}
+}
+
+// Override
+func (claim *BaseLuckyMoneyClaim) Receiver() ID {
+	return ParseID(claim.Get("ID"))
+}
+
+// Override
+func (claim *BaseLuckyMoneyClaim) Amount() float64 {
+	return claim.GetFloat64("amount", 0)
+}
+
+// Override
+func (claim *BaseLuckyMoneyClaim) Time() Time {
+	return claim.GetTime("time", nil)
+}
+
+/**
+ *  Split Bill Content
+ */
+
+type BaseSplitBillContent struct {
+	//SplitBillContent
+	*BaseMoneyContent
+}
+
+func NewBaseSplitBillContent(dict StringKeyMap, currency string, total float64, group ID) *BaseSplitBillContent {
+	if dict != nil {
+		// init split bill content with map
+		return &BaseSplitBillContent{
+			BaseMoneyContent: NewBaseMoneyContent(dict, "", "", 0),
+		}
+	}
+	// new split bill content
+	content := &BaseSplitBillContent{
+		BaseMoneyContent: NewBaseMoneyContent(nil, ContentType.SPLIT_BILL, currency, total),
+	}
+	if group != nil {
+		content.SetGroup(group)
+	}
+	content.Set("shares", StringKeyMap{})
+	return content
+}
+
+// protected
+func (content *BaseSplitBillContent) Shares() StringKeyMap {
+	shares := FetchMap(content.Get("shares"))
+	if shares == nil {
+		shares = StringKeyMap{}
+		content.Set("shares", shares)
+	}
+	return shares
+}
+
+func (content *BaseSplitBillContent) share(member ID, create bool) StringKeyMap {
+	shares := content.Shares()
+	info := FetchMap(shares[member.String()])
+	if info == nil && create {
+		info = StringKeyMap{
+			"amount": 0.0,
+			"status": SPLIT_BILL_UNPAID,
+		}
+		shares[member.String()] = info
+	}
+	return info
+}
+
+// Override
+func (content *BaseSplitBillContent) Members() []ID {
+	shares := content.Shares()
+	keys := MapKeys(shares)
+	sort.Strings(keys)
+	members := make([]ID, 0, len(keys))
+	for _, key := range keys {
+		member := ParseID(key)
+		if member != nil {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// Override
+func (content *BaseSplitBillContent) Share(member ID) float64 {
+	info := content.share(member, false)
+	if info == nil {
+		return 0
+	}
+	return ConvertFloat64(info["amount"], 0)
+}
+
+// Override
+func (content *BaseSplitBillContent) SetShare(member ID, amount float64) {
+	info := content.share(member, true)
+	info["amount"] = amount
+}
+
+// Override
+func (content *BaseSplitBillContent) Status(member ID) string {
+	info := content.share(member, false)
+	if info == nil {
+		return ""
+	}
+	return ConvertString(info["status"], SPLIT_BILL_UNPAID)
+}
+
+// Override
+func (content *BaseSplitBillContent) SetStatus(member ID, status string) {
+	info := content.share(member, true)
+	info["status"] = status
+}
+
+// Override
+func (content *BaseSplitBillContent) MarkPaid(member ID, sn SerialNumberType) {
+	info := content.share(member, true)
+	info["status"] = SPLIT_BILL_PAID
+	if sn > 0 {
+		info["transfer"] = sn
+	}
+}
+
+// Override
+func (content *BaseSplitBillContent) IsSettled() bool {
+	members := content.Members()
+	if len(members) == 0 {
+		return false
+	}
+	for _, member := range members {
+		if content.Status(member) != SPLIT_BILL_PAID {
+			return false
+		}
+	}
+	return true
+}
@@ -77,7 +77,7 @@ func NewBaseContent(dict StringKeyMap, msgType MessageType) *BaseContent {
 	dict["sn"] = sn
 	dict["time"] = TimeToFloat64(when)
 	return &BaseContent{
-		Dictionary: NewDictionary(nil),
+		Dictionary: NewDictionary(dict),
 		msgType:    msgType,
 		sn:         sn,
 		time:       when,
@@ -159,5 +159,8 @@ func NewBaseCommand(dict StringKeyMap, msgType MessageType, cmd string) *BaseCom
 // Override
 func (content *BaseCommand) CMD() string {
 	helper := GetGeneralCommandHelper()
+	if helper == nil {
+		return GetCommandName(content.Map(), "")
+	}
 	return helper.GetCMD(content.Map(), "")
 }
@@ -91,10 +91,35 @@ func NewReceiptCommand(text string, head Envelope, body Content) ReceiptCommand
 	return NewBaseReceiptCommand(nil, text, origin)
 }
 
+func NewReceiptCommandWithContext(ctx *Context, text string, head Envelope, body Content) ReceiptCommand {
+	origin := ctx.PurifyForReceipt(head, body)
+	return NewBaseReceiptCommand(nil, text, origin)
+}
+
 func NewReceiptCommandWithMap(dict StringKeyMap) Command {
 	return NewBaseReceiptCommand(dict, "", nil)
 }
 
+/**
+ *  Account History
+ */
+
+func NewRegisterCommand(did ID, meta Meta, visa Visa) RegisterCommand {
+	return NewBaseRegisterCommand(nil, did, meta, visa)
+}
+
+func NewRegisterCommandWithMap(dict StringKeyMap) Command {
+	return NewBaseRegisterCommand(dict, nil, nil, nil)
+}
+
+func NewSuicideCommand(did ID, farewell string, successor ID) SuicideCommand {
+	return NewBaseSuicideCommand(nil, did, farewell, successor)
+}
+
+func NewSuicideCommandWithMap(dict StringKeyMap) Command {
+	return NewBaseSuicideCommand(dict, nil, "", nil)
+}
+
 /**
  *  Group History
  */
@@ -156,3 +181,81 @@ func NewResetCommand(group ID, members []ID) ResetCommand {
 func NewResetCommandWithMap(dict StringKeyMap) Command {
 	return NewResetGroupCommand(dict, nil, nil)
 }
+
+// Found
+
+func NewFoundCommand(group ID, members []ID) FoundCommand {
+	return NewFoundGroupCommand(nil, group, members)
+}
+
+func NewFoundCommandWithMap(dict StringKeyMap) Command {
+	return NewFoundGroupCommand(dict, nil, nil)
+}
+
+// Abdicate
+
+func NewAbdicateCommand(group ID, owner ID) AbdicateCommand {
+	return NewAbdicateGroupCommand(nil, group, owner)
+}
+
+func NewAbdicateCommandWithMap(dict StringKeyMap) Command {
+	return NewAbdicateGroupCommand(dict, nil, nil)
+}
+
+// Hire
+
+func NewHireCommand(group ID, admins []ID, bots []ID) HireCommand {
+	return NewHireGroupCommand(nil, group, admins, bots)
+}
+
+func NewHireCommandWithMap(dict StringKeyMap) Command {
+	return NewHireGroupCommand(dict, nil, nil, nil)
+}
+
+// Fire
+
+func NewFireCommand(group ID, admins []ID, bots []ID) FireCommand {
+	return NewFireGroupCommand(nil, group, admins, bots)
+}
+
+func NewFireCommandWithMap(dict StringKeyMap) Command {
+	return NewFireGroupCommand(dict, nil, nil, nil)
+}
+
+// Resign
+
+func NewResignCommand(group ID, admins []ID) ResignCommand {
+	return NewResignGroupCommand(nil, group, admins)
+}
+
+func NewResignCommandWithMap(dict StringKeyMap) Command {
+	return NewResignGroupCommand(dict, nil, nil)
+}
+
+/**
+ *  Handshake Command
+ */
+
+// NewHandshakeStartCommand creates 'Hello world!' without session key (C -> S)
+func NewHandshakeStartCommand() HandshakeCommand {
+	return NewBaseHandshakeCommand(nil, HANDSHAKE_HELLO, "")
+}
+
+// NewHandshakeRestartCommand creates 'Hello world!' with session key (C -> S)
+func NewHandshakeRestartCommand(sessionKey string) HandshakeCommand {
+	return NewBaseHandshakeCommand(nil, HANDSHAKE_HELLO, sessionKey)
+}
+
+// NewHandshakeAgainCommand creates 'DIM?' with new session key (S -> C)
+func NewHandshakeAgainCommand(sessionKey string) HandshakeCommand {
+	return NewBaseHandshakeCommand(nil, HANDSHAKE_AGAIN, sessionKey)
+}
+
+// NewHandshakeSuccessCommand creates 'DIM!' (S -> C)
+func NewHandshakeSuccessCommand(sessionKey string) HandshakeCommand {
+	return NewBaseHandshakeCommand(nil, HANDSHAKE_SUCCESS, sessionKey)
+}
+
+func NewHandshakeCommandWithMap(dict StringKeyMap) Command {
+	return NewBaseHandshakeCommand(dict, "", "")
+}
@@ -31,6 +31,7 @@
 package dkd
 
 import (
+	. "github.com/dimchat/core-go/format"
 	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/crypto"
@@ -123,6 +124,16 @@ func NewArrayContentWithMap(dict StringKeyMap) Content {
 	return NewListContent(dict, nil)
 }
 
+// ArrayContentParserWithContext returns a parser for array content,
+// which parses the inner contents with ctx
+func ArrayContentParserWithContext(ctx *Context) func(dict StringKeyMap) Content {
+	return func(dict StringKeyMap) Content {
+		content := NewListContent(dict, nil)
+		content.context = ctx
+		return content
+	}
+}
+
 /**
  *  File Contents
  */
@@ -195,6 +206,32 @@ func NewVideoContentWithMap(dict StringKeyMap) Content {
 	return NewVideoFileContent(dict, nil, "", nil, nil, nil)
 }
 
+// FileContentParserWithContext returns a parser for file contents (file, image,
+// audio & video) which creates the wrappers with the factory of ctx,
+// so the embedded data will be decoded with the coders of ctx
+func FileContentParserWithContext(ctx *FormatContext) func(dict StringKeyMap) Content {
+	return func(dict StringKeyMap) Content {
+		var content FileContent
+		var base *BaseFileContent
+		switch ConvertString(dict["type"], "") {
+		case ContentType.IMAGE:
+			image := NewImageFileContent(dict, nil, "", nil, nil, nil)
+			content, base = image, image.BaseFileContent
+		case ContentType.AUDIO:
+			audio := NewAudioFileContent(dict, nil, "", nil, nil)
+			content, base = audio, audio.BaseFileContent
+		case ContentType.VIDEO:
+			video := NewVideoFileContent(dict, nil, "", nil, nil, nil)
+			content, base = video, video.BaseFileContent
+		default:
+			base = NewBaseFileContent(dict, "", nil, "", nil, nil)
+			content = base
+		}
+		base.wrapper = ctx.CreateTransportableFileWrapper(dict, nil, "", nil, nil)
+		return content
+	}
+}
+
 /**
  *  Money Contents
  */
@@ -215,6 +252,51 @@ func NewTransferContentWithMap(dict StringKeyMap) Content {
 	return NewTransferMoneyContent(dict, "", 0)
 }
 
+func NewClaimPaymentContent(currency string, amount float64, payee ID) ClaimPaymentContent {
+	return NewBaseClaimPaymentContent(nil, currency, amount, payee)
+}
+
+func NewClaimPaymentContentWithMap(dict StringKeyMap) Content {
+	return NewBaseClaimPaymentContent(dict, "", 0, nil)
+}
+
+func NewSplitBillContent(currency string, total float64, group ID) SplitBillContent {
+	return NewBaseSplitBillContent(nil, currency, total, group)
+}
+
+func NewSplitBillContentWithMap(dict StringKeyMap) Content {
+	return NewBaseSplitBillContent(dict, "", 0, nil)
+}
+
+func NewLuckyMoneyContent(currency string, amount float64, count int, mode string, text string) LuckyMoneyContent {
+	return NewBaseLuckyMoneyContent(nil, currency, amount, count, mode, text)
+}
+
+func NewLuckyMoneyContentWithMap(dict StringKeyMap) Content {
+	return NewBaseLuckyMoneyContent(dict, "", 0, 0, "", "")
+}
+
+func NewLuckyMoneyClaim(receiver ID, amount float64, when Time) LuckyMoneyClaim {
+	return NewBaseLuckyMoneyClaim(nil, receiver, amount, when)
+}
+
+/**
+ *  Customized Content
+ */
+
+func NewCustomizedContent(app, mod, act string) CustomizedContent {
+	return NewAppCustomizedContent(nil, ContentType.CUSTOMIZED, app, mod, act)
+}
+
+// NewApplicationContent creates customized content for application only (0xA0)
+func NewApplicationContent(app, mod, act string) CustomizedContent {
+	return NewAppCustomizedContent(nil, ContentType.APPLICATION, app, mod, act)
+}
+
+func NewCustomizedContentWithMap(dict StringKeyMap) Content {
+	return NewAppCustomizedContent(dict, "", "", "", "")
+}
+
 /**
  *  Quote Content
  */
@@ -224,6 +306,11 @@ func NewQuoteContent(text string, head Envelope, body Content) QuoteContent {
 	return NewBaseQuoteContent(nil, text, origin)
 }
 
+func NewQuoteContentWithContext(ctx *Context, text string, head Envelope, body Content) QuoteContent {
+	origin := ctx.PurifyForQuote(head, body)
+	return NewBaseQuoteContent(nil, text, origin)
+}
+
 func NewQuoteContentWithMap(dict StringKeyMap) Content {
 	return NewBaseQuoteContent(dict, "", nil)
 }
@@ -69,7 +69,7 @@ func NewBaseFileContent(dict StringKeyMap,
 	content := &BaseFileContent{
 		BaseContent: NewBaseContent(nil, msgType),
 	}
-	content.wrapper = CreateTransportableFileWrapper(content.Map(), data, filename, url, password)
+	content.wrapper = CreateTransportableFileWrapper(content.BaseContent.Map(), data, filename, url, password)
 	return content
 }
 
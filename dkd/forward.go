@@ -158,6 +158,9 @@ type ListContent struct {
 	*BaseContent
 
 	list []Content
+
+	// context parses the contents (nil means the default context)
+	context *Context
 }
 
 func NewListContent(dict StringKeyMap, contents []Content) *ListContent {
@@ -183,10 +186,12 @@ func (content *ListContent) Contents() []Content {
 	contents := content.list
 	if contents == nil {
 		array := content.Get("contents")
-		if array != nil {
-			contents = ContentConvert(array)
-		} else {
+		if array == nil {
 			contents = []Content{}
+		} else if ctx := content.context; ctx != nil {
+			contents = ctx.ContentConvert(array)
+		} else {
+			contents = ContentConvert(array)
 		}
 		content.list = contents
 	}
@@ -173,3 +173,126 @@ func NewResetGroupCommand(dict StringKeyMap, group ID, members []ID) *ResetGroup
 		BaseGroupCommand: NewBaseGroupCommand(dict, RESET, group, members),
 	}
 }
+
+type FoundGroupCommand struct {
+	//FoundCommand
+	*BaseGroupCommand
+}
+
+func NewFoundGroupCommand(dict StringKeyMap, group ID, members []ID) *FoundGroupCommand {
+	return &FoundGroupCommand{
+		BaseGroupCommand: NewBaseGroupCommand(dict, FOUND, group, members),
+	}
+}
+
+type AbdicateGroupCommand struct {
+	//AbdicateCommand
+	*BaseGroupCommand
+}
+
+func NewAbdicateGroupCommand(dict StringKeyMap, group ID, owner ID) *AbdicateGroupCommand {
+	content := &AbdicateGroupCommand{
+		BaseGroupCommand: NewBaseGroupCommand(dict, ABDICATE, group, nil),
+	}
+	if owner != nil {
+		content.SetNewOwner(owner)
+	}
+	return content
+}
+
+// Override
+func (content *AbdicateGroupCommand) NewOwner() ID {
+	return ParseID(content.Get("owner"))
+}
+
+// Override
+func (content *AbdicateGroupCommand) SetNewOwner(owner ID) {
+	content.SetStringer("owner", owner)
+}
+
+/**
+ *  Administrator Commands
+ */
+
+// AdminGroupCommand is the base for 'hire', 'fire' & 'resign'
+type AdminGroupCommand struct {
+	*BaseGroupCommand
+}
+
+func NewAdminGroupCommand(dict StringKeyMap, cmd string, group ID, admins []ID, bots []ID) *AdminGroupCommand {
+	content := &AdminGroupCommand{
+		BaseGroupCommand: NewBaseGroupCommand(dict, cmd, group, nil),
+	}
+	if admins != nil {
+		content.SetAdministrators(admins)
+	}
+	if bots != nil {
+		content.SetAssistants(bots)
+	}
+	return content
+}
+
+func (content *AdminGroupCommand) Administrators() []ID {
+	admins := content.Get("administrators")
+	if admins == nil {
+		return nil
+	}
+	return IDConvert(admins)
+}
+
+func (content *AdminGroupCommand) SetAdministrators(admins []ID) {
+	if admins == nil {
+		content.Remove("administrators")
+	} else {
+		content.Set("administrators", IDRevert(admins))
+	}
+}
+
+func (content *AdminGroupCommand) Assistants() []ID {
+	bots := content.Get("assistants")
+	if bots == nil {
+		return nil
+	}
+	return IDConvert(bots)
+}
+
+func (content *AdminGroupCommand) SetAssistants(bots []ID) {
+	if bots == nil {
+		content.Remove("assistants")
+	} else {
+		content.Set("assistants", IDRevert(bots))
+	}
+}
+
+type HireGroupCommand struct {
+	//HireCommand
+	*AdminGroupCommand
+}
+
+func NewHireGroupCommand(dict StringKeyMap, group ID, admins []ID, bots []ID) *HireGroupCommand {
+	return &HireGroupCommand{
+		AdminGroupCommand: NewAdminGroupCommand(dict, HIRE, group, admins, bots),
+	}
+}
+
+type FireGroupCommand struct {
+	//FireCommand
+	*AdminGroupCommand
+}
+
+func NewFireGroupCommand(dict StringKeyMap, group ID, admins []ID, bots []ID) *FireGroupCommand {
+	return &FireGroupCommand{
+		AdminGroupCommand: NewAdminGroupCommand(dict, FIRE, group, admins, bots),
+	}
+}
+
+type ResignGroupCommand struct {
+	//ResignCommand
+	*AdminGroupCommand
+}
+
+func NewResignGroupCommand(dict StringKeyMap, group ID, admins []ID) *ResignGroupCommand {
+	return &ResignGroupCommand{
+		AdminGroupCommand: NewAdminGroupCommand(dict, RESIGN, group, admins, nil),
+	}
+}
@@ -0,0 +1,418 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Handshake Command
+ */
+
+type BaseHandshakeCommand struct {
+	//HandshakeCommand
+	*BaseCommand
+}
+
+func NewBaseHandshakeCommand(dict StringKeyMap, title, sessionKey string) *BaseHandshakeCommand {
+	if dict != nil {
+		// init handshake command with map
+		return &BaseHandshakeCommand{
+			BaseCommand: NewBaseCommand(dict, "", ""),
+		}
+	}
+	// new handshake command
+	content := &BaseHandshakeCommand{
+		BaseCommand: NewBaseCommand(nil, "", HANDSHAKE),
+	}
+	// text message
+	content.Set("title", title)
+	// session key
+	if sessionKey != "" {
+		content.Set("session", sessionKey)
+	}
+	// OK
+	return content
+}
+
+// Override
+func (content *BaseHandshakeCommand) Title() string {
+	return content.GetString("title", "")
+}
+
+// Override
+func (content *BaseHandshakeCommand) SessionKey() string {
+	return content.GetString("session", "")
+}
+
+// Override
+func (content *BaseHandshakeCommand) State() HandshakeState {
+	title := content.Title()
+	session := content.SessionKey()
+	return GetHandshakeState(title, session)
+}
+
+// Reasons for failing to handshake
+var (
+	ErrHandshakeState   = errors.New("unexpected handshake state")
+	ErrHandshakeSession = errors.New("handshake session key missing")
+	ErrHandshakeReject  = errors.New("handshake session key not match")
+)
+
+/**
+ *  Session Key Store
+ */
+
+// SessionKeyStore keeps the session keys for handshake
+//
+// On client side, it keeps the session keys issued by stations;
+// on station side, it keeps the session keys issued for clients.
+type SessionKeyStore interface {
+
+	// GetSessionKey returns the session key for this entity,
+	// empty string if not found or expired
+	GetSessionKey(did ID) string
+
+	// SetSessionKey saves the session key for this entity
+	//
+	// Parameters:
+	//   - did     - client ID / station ID
+	//   - key     - session key
+	//   - expires - when the session key expires (nil means never)
+	SetSessionKey(did ID, key string, expires Time)
+
+	// RemoveSessionKey forgets the session key for this entity
+	RemoveSessionKey(did ID)
+}
+
+// MemorySessionKeyStore is a SessionKeyStore in memory
+type MemorySessionKeyStore struct {
+	//SessionKeyStore
+
+	mutex sync.Mutex
+
+	sessions map[string]*sessionRecord // ID string => record
+}
+
+type sessionRecord struct {
+	key     string
+	expires time.Time // zero means never
+}
+
+func NewMemorySessionKeyStore() *MemorySessionKeyStore {
+	return &MemorySessionKeyStore{
+		sessions: make(map[string]*sessionRecord),
+	}
+}
+
+// Override
+func (store *MemorySessionKeyStore) GetSessionKey(did ID) string {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	record := store.sessions[did.String()]
+	if record == nil {
+		return ""
+	} else if !record.expires.IsZero() && !time.Now().Before(record.expires) {
+		// session expired
+		delete(store.sessions, did.String())
+		return ""
+	}
+	return record.key
+}
+
+// Override
+func (store *MemorySessionKeyStore) SetSessionKey(did ID, key string, expires Time) {
+	record := &sessionRecord{
+		key: key,
+	}
+	if !TimeIsNil(expires) {
+		record.expires = time.UnixMicro(int64(TimeToFloat64(expires) * 1e6))
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.sessions[did.String()] = record
+}
+
+// Override
+func (store *MemorySessionKeyStore) RemoveSessionKey(did ID) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.sessions, did.String())
+}
+
+/**
+ *  Client Handshake
+ *  ~~~~~~~~~~~~~~~~
+ *  Drives the handshake with a station:
+ *      Init    -> Start   : send 'Hello world!' without session key
+ *      Init    -> Restart : send 'Hello world!' with session key stored
+ *      Start   -> Restart : received 'DIM?' with new session key
+ *      Restart -> Success : received 'DIM!'
+ *
+ *  If no response received in timeout, the request should be sent again,
+ *  check it with CheckTimeout().
+ */
+type ClientHandshake struct {
+	mutex sync.Mutex
+
+	station ID
+	store   SessionKeyStore
+
+	// waiting for response
+	timeout  time.Duration
+	lastTime time.Time
+
+	state HandshakeState
+}
+
+func NewClientHandshake(station ID, store SessionKeyStore, timeout time.Duration) *ClientHandshake {
+	return &ClientHandshake{
+		station: station,
+		store:   store,
+		timeout: timeout,
+		state:   HandshakeInit,
+	}
+}
+
+func (hs *ClientHandshake) Station() ID {
+	return hs.station
+}
+
+func (hs *ClientHandshake) State() HandshakeState {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	return hs.state
+}
+
+// SessionKey returns the session key issued by the station
+func (hs *ClientHandshake) SessionKey() string {
+	return hs.store.GetSessionKey(hs.station)
+}
+
+// IsSuccess checks whether the session is accepted by the station
+func (hs *ClientHandshake) IsSuccess() bool {
+	return hs.State() == HandshakeSuccess
+}
+
+// Start builds the first handshake request ('Hello world!'),
+// with the session key if it was stored before
+func (hs *ClientHandshake) Start() HandshakeCommand {
+	session := hs.store.GetSessionKey(hs.station)
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	if session == "" {
+		hs.state = HandshakeStart
+	} else {
+		hs.state = HandshakeRestart
+	}
+	hs.lastTime = time.Now()
+	return NewBaseHandshakeCommand(nil, HANDSHAKE_HELLO, session)
+}
+
+// HandleResponse processes the handshake response from the station
+//
+// 'DIM!' is accepted only when it carries the session key stored here.
+//
+// Returns: next request (nil if no need to send)
+func (hs *ClientHandshake) HandleResponse(cmd HandshakeCommand) (HandshakeCommand, error) {
+	switch cmd.State() {
+	case HandshakeAgain:
+		// station asks to handshake again with the new session key,
+		// this may happen any time after the session expired
+		session := cmd.SessionKey()
+		if session == "" {
+			return nil, ErrHandshakeSession
+		}
+		hs.store.SetSessionKey(hs.station, session, nil)
+		hs.mutex.Lock()
+		defer hs.mutex.Unlock()
+		hs.state = HandshakeRestart
+		hs.lastTime = time.Now()
+		return NewBaseHandshakeCommand(nil, HANDSHAKE_HELLO, session), nil
+	case HandshakeSuccess:
+		// the station must accept the session key stored here
+		session := hs.store.GetSessionKey(hs.station)
+		if session == "" || !sessionKeyEqual(session, cmd.SessionKey()) {
+			return nil, ErrHandshakeReject
+		}
+		hs.mutex.Lock()
+		defer hs.mutex.Unlock()
+		if hs.state != HandshakeStart && hs.state != HandshakeRestart {
+			// not waiting for response
+			return nil, ErrHandshakeState
+		}
+		hs.state = HandshakeSuccess
+		return nil, nil
+	default:
+		return nil, ErrHandshakeState
+	}
+}
+
+// CheckTimeout builds the request again if no response received in time
+//
+// Returns: handshake request (nil if no need to resend)
+func (hs *ClientHandshake) CheckTimeout(now time.Time) HandshakeCommand {
+	hs.mutex.Lock()
+	waiting := hs.state == HandshakeStart || hs.state == HandshakeRestart
+	expired := now.Sub(hs.lastTime) >= hs.timeout
+	hs.mutex.Unlock()
+	if waiting && expired {
+		return hs.Start()
+	}
+	return nil
+}
+
+// Reset forgets the handshake state, call it after the connection lost
+func (hs *ClientHandshake) Reset() {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	hs.state = HandshakeInit
+}
+
+/**
+ *  Station Handshake
+ *  ~~~~~~~~~~~~~~~~~
+ *  Answers the handshake requests from clients:
+ *      Start                  -> Again   : issue new session key
+ *      Restart (key mismatch) -> Again   : issue new session key
+ *      Restart (key matched)  -> Success : session accepted
+ *
+ *  The session keys are kept in the store until expired (ttl).
+ */
+type StationHandshake struct {
+	mutex sync.Mutex
+
+	store SessionKeyStore
+
+	// session key lifetime (0 means never expires)
+	ttl time.Duration
+
+	// session key generator
+	generate func() string
+
+	// client ID string => accepted session key
+	accepted map[string]string
+}
+
+func NewStationHandshake(store SessionKeyStore, ttl time.Duration) *StationHandshake {
+	return &StationHandshake{
+		store:    store,
+		ttl:      ttl,
+		generate: GenerateSessionKey,
+		accepted: make(map[string]string),
+	}
+}
+
+// SetKeyGenerator replaces the session key generator
+func (hs *StationHandshake) SetKeyGenerator(generate func() string) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	hs.generate = generate
+}
+
+// HandleRequest processes the handshake request from client
+//
+// Returns: handshake response
+func (hs *StationHandshake) HandleRequest(client ID, cmd HandshakeCommand) (HandshakeCommand, error) {
+	var session string
+	switch cmd.State() {
+	case HandshakeStart:
+		// new session
+	case HandshakeRestart:
+		session = hs.store.GetSessionKey(client)
+		if session != "" && sessionKeyEqual(session, cmd.SessionKey()) {
+			// session key matched
+			hs.mutex.Lock()
+			hs.accepted[client.String()] = session
+			hs.mutex.Unlock()
+			return NewBaseHandshakeCommand(nil, HANDSHAKE_SUCCESS, session), nil
+		}
+		// session key expired, or not match
+	default:
+		return nil, ErrHandshakeState
+	}
+	// issue new session key
+	hs.mutex.Lock()
+	delete(hs.accepted, client.String())
+	generate := hs.generate
+	hs.mutex.Unlock()
+	session = generate()
+	var expires Time
+	if hs.ttl > 0 {
+		expires = time.Now().Add(hs.ttl)
+	}
+	hs.store.SetSessionKey(client, session, expires)
+	return NewBaseHandshakeCommand(nil, HANDSHAKE_AGAIN, session), nil
+}
+
+// IsAccepted checks whether the client has finished handshake,
+// and its session key not expired
+func (hs *StationHandshake) IsAccepted(client ID) bool {
+	session := hs.store.GetSessionKey(client)
+	if session == "" {
+		return false
+	}
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	return sessionKeyEqual(hs.accepted[client.String()], session)
+}
+
+// Remove forgets the session of client, call it after the connection lost
+func (hs *StationHandshake) Remove(client ID) {
+	hs.mutex.Lock()
+	delete(hs.accepted, client.String())
+	hs.mutex.Unlock()
+	hs.store.RemoveSessionKey(client)
+}
+
+// compare session keys in constant time, to stop timing attacks
+func sessionKeyEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// GenerateSessionKey creates a random session key (32 hex chars)
+func GenerateSessionKey() string {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,478 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+)
+
+// Reasons for rejecting a group command
+var (
+	ErrGroupOutOfOrder = errors.New("group command out of order")
+	ErrGroupPermission = errors.New("group command not permitted")
+	ErrGroupConflict   = errors.New("group command conflicts with group state")
+	ErrGroupTime       = errors.New("group command time out of range")
+)
+
+// GroupHistory is a group command with its sender (from the message envelope)
+type GroupHistory struct {
+	Sender  ID
+	Command GroupCommand
+
+	// Time of the message envelope (or when it's received), 0 means unknown
+	Time float64
+}
+
+// FounderVerifier checks whether the sender of 'found' command is the
+// real founder of the group (e.g.: by the group meta or bulletin),
+// it's called with the state locked, so don't call back into the state
+type FounderVerifier func(group ID, founder ID) bool
+
+// GroupTimeChecker checks the command time against the message time,
+// so the sender cannot backdate a command to get it applied before others
+type GroupTimeChecker func(cmdTime, msgTime float64) bool
+
+// NewGroupTimeBound accepts commands with time in [msgTime - maxDelay, msgTime + maxSkew]
+func NewGroupTimeBound(maxDelay, maxSkew time.Duration) GroupTimeChecker {
+	delay := maxDelay.Seconds()
+	skew := maxSkew.Seconds()
+	return func(cmdTime, msgTime float64) bool {
+		return cmdTime >= msgTime-delay && cmdTime <= msgTime+skew
+	}
+}
+
+/**
+ *  Group State
+ *  ~~~~~~~~~~~
+ *  Computes the membership by applying group commands in order:
+ *
+ *      found    - (anyone)         creates the group, sender becomes the owner
+ *      invite   - (owner, admins)  adds members
+ *      expel    - (owner, admins)  removes members (deprecated)
+ *      reset    - (owner, admins)  replaces members
+ *      join     - (non-members)    asks to join, no change until invited
+ *      quit     - (members)        leaves the group (owner must abdicate first)
+ *      hire     - (owner)          appoints administrators & assistants
+ *      fire     - (owner)          dismisses administrators & assistants
+ *      resign   - (admins)         steps down from administrator
+ *      abdicate - (owner)          transfers the ownership to a member
+ *
+ *  The commands are ordered by (time, sender, sn), any command not after
+ *  the last applied one will be rejected, so every client replaying the
+ *  same history converges on the same state.
+ *
+ *  The 'found' command is accepted only from the owner given when creating
+ *  the state, or the founder approved by the FounderVerifier; and if the
+ *  GroupTimeChecker is set, the command time must be bound to the time of
+ *  its message.
+ */
+type GroupState struct {
+	mutex sync.Mutex
+
+	group ID
+	owner ID
+
+	members    []ID // owner first
+	admins     []ID
+	assistants []ID
+
+	// order key of the last applied command
+	lastTime   float64
+	lastSender string
+	lastSN     SerialNumberType
+
+	verifier    FounderVerifier
+	timeChecker GroupTimeChecker
+}
+
+// NewGroupState creates the state of a group,
+// if owner is nil, the 'found' command is expected first,
+// which must be approved by the FounderVerifier
+func NewGroupState(group ID, owner ID, members []ID) *GroupState {
+	state := &GroupState{
+		group: group,
+		owner: owner,
+	}
+	if owner != nil {
+		state.members = addMembers([]ID{owner}, members)
+	}
+	return state
+}
+
+func (state *GroupState) Group() ID {
+	return state.group
+}
+
+func (state *GroupState) Owner() ID {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return state.owner
+}
+
+func (state *GroupState) Members() []ID {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return append([]ID{}, state.members...)
+}
+
+func (state *GroupState) Administrators() []ID {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return append([]ID{}, state.admins...)
+}
+
+func (state *GroupState) Assistants() []ID {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return append([]ID{}, state.assistants...)
+}
+
+// SetFounderVerifier sets the checker for the founder of 'found' command
+func (state *GroupState) SetFounderVerifier(verifier FounderVerifier) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.verifier = verifier
+}
+
+// SetTimeChecker sets the checker for command time,
+// after that the history without message time will be rejected
+func (state *GroupState) SetTimeChecker(checker GroupTimeChecker) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.timeChecker = checker
+}
+
+// LastTime returns the time of the last applied command (0 if none)
+func (state *GroupState) LastTime() float64 {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return state.lastTime
+}
+
+// Replay applies the history sorted by (time, sender, sn)
+//
+// Returns: rejected commands
+func (state *GroupState) Replay(history []GroupHistory) []GroupHistory {
+	var rejected []GroupHistory
+	sorted := make([]GroupHistory, 0, len(history))
+	for _, item := range history {
+		if item.Sender == nil || item.Command == nil {
+			rejected = append(rejected, item)
+		} else {
+			sorted = append(sorted, item)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		return compareGroupHistory(a.Command.GetFloat64("time", 0), a.Sender.String(), a.Command.SN(),
+			b.Command.GetFloat64("time", 0), b.Sender.String(), b.Command.SN()) < 0
+	})
+	for _, item := range sorted {
+		if err := state.ApplyHistory(item); err != nil {
+			rejected = append(rejected, item)
+		}
+	}
+	return rejected
+}
+
+// Apply checks the permission of sender and applies the command
+// (without message time)
+//
+// Returns: ErrGroupOutOfOrder / ErrGroupPermission / ErrGroupConflict / ErrGroupTime
+func (state *GroupState) Apply(sender ID, cmd GroupCommand) error {
+	return state.ApplyHistory(GroupHistory{
+		Sender:  sender,
+		Command: cmd,
+	})
+}
+
+// ApplyHistory checks the command time & permission of sender,
+// and applies the command
+//
+// Returns: ErrGroupOutOfOrder / ErrGroupPermission / ErrGroupConflict / ErrGroupTime
+func (state *GroupState) ApplyHistory(item GroupHistory) error {
+	sender, cmd := item.Sender, item.Command
+	if sender == nil || cmd == nil {
+		return ErrGroupConflict
+	} else if group := cmd.Group(); group == nil || !group.Equal(state.group) {
+		return ErrGroupConflict
+	}
+	when := cmd.GetFloat64("time", 0)
+	if when <= 0 {
+		return ErrGroupOutOfOrder
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	// 0. check time
+	if checker := state.timeChecker; checker != nil {
+		if item.Time <= 0 || !checker(when, item.Time) {
+			return ErrGroupTime
+		}
+	}
+	// 1. check order
+	if state.lastTime > 0 && compareGroupHistory(when, sender.String(), cmd.SN(),
+		state.lastTime, state.lastSender, state.lastSN) <= 0 {
+		return ErrGroupOutOfOrder
+	}
+	// 2. check permission & apply
+	var err error
+	if state.lastTime == 0 && cmd.CMD() == FOUND {
+		err = state.found(sender, cmd)
+	} else if state.owner == nil {
+		// not founded yet
+		err = ErrGroupConflict
+	} else {
+		err = state.apply(sender, cmd)
+	}
+	if err != nil {
+		return err
+	}
+	// 3. update order key
+	state.lastTime = when
+	state.lastSender = sender.String()
+	state.lastSN = cmd.SN()
+	return nil
+}
+
+// the founder must be the known owner, or approved by the verifier
+func (state *GroupState) found(sender ID, cmd GroupCommand) error {
+	if state.owner != nil {
+		if !sender.Equal(state.owner) {
+			return ErrGroupPermission
+		}
+	} else if state.verifier == nil || !state.verifier(state.group, sender) {
+		return ErrGroupPermission
+	}
+	state.owner = sender
+	// owner first
+	state.members = addMembers([]ID{sender}, addMembers(state.members, cmd.Members()))
+	return nil
+}
+
+// check permission & conflicts before changing the state
+func (state *GroupState) apply(sender ID, cmd GroupCommand) error {
+	isOwner := sender.Equal(state.owner)
+	isAdmin := containsMember(state.admins, sender)
+	switch cmd.CMD() {
+	case FOUND:
+		// already founded
+		return ErrGroupConflict
+
+	case INVITE:
+		if !isOwner && !isAdmin {
+			return ErrGroupPermission
+		}
+		state.members = addMembers(state.members, cmd.Members())
+
+	case EXPEL:
+		if !isOwner && !isAdmin {
+			return ErrGroupPermission
+		}
+		return state.reset(isOwner, removeMembers(state.members, cmd.Members()))
+
+	case RESET:
+		if !isOwner && !isAdmin {
+			return ErrGroupPermission
+		}
+		return state.reset(isOwner, cmd.Members())
+
+	case JOIN:
+		if containsMember(state.members, sender) {
+			return ErrGroupConflict
+		}
+		// waiting for invitation
+
+	case QUIT:
+		if isOwner || !containsMember(state.members, sender) {
+			return ErrGroupConflict
+		}
+		state.members = removeMembers(state.members, []ID{sender})
+		state.admins = removeMembers(state.admins, []ID{sender})
+
+	case HIRE:
+		if !isOwner {
+			return ErrGroupPermission
+		}
+		hire, ok := cmd.(HireCommand)
+		if !ok {
+			return ErrGroupConflict
+		}
+		admins := hire.Administrators()
+		for _, item := range admins {
+			if item.Equal(state.owner) || !containsMember(state.members, item) {
+				return ErrGroupConflict
+			}
+		}
+		state.admins = addMembers(state.admins, admins)
+		state.assistants = addMembers(state.assistants, hire.Assistants())
+
+	case FIRE:
+		if !isOwner {
+			return ErrGroupPermission
+		}
+		fire, ok := cmd.(FireCommand)
+		if !ok {
+			return ErrGroupConflict
+		}
+		admins := fire.Administrators()
+		for _, item := range admins {
+			if !containsMember(state.admins, item) {
+				return ErrGroupConflict
+			}
+		}
+		state.admins = removeMembers(state.admins, admins)
+		state.assistants = removeMembers(state.assistants, fire.Assistants())
+
+	case RESIGN:
+		if !isAdmin {
+			return ErrGroupPermission
+		}
+		resign, ok := cmd.(ResignCommand)
+		if !ok {
+			return ErrGroupConflict
+		}
+		for _, item := range resign.Administrators() {
+			if !item.Equal(sender) {
+				// cannot resign for others
+				return ErrGroupPermission
+			}
+		}
+		state.admins = removeMembers(state.admins, []ID{sender})
+
+	case ABDICATE:
+		if !isOwner {
+			return ErrGroupPermission
+		}
+		abdicate, ok := cmd.(AbdicateCommand)
+		if !ok {
+			return ErrGroupConflict
+		}
+		owner := abdicate.NewOwner()
+		if owner == nil || owner.Equal(sender) || !containsMember(state.members, owner) {
+			return ErrGroupConflict
+		}
+		state.owner = owner
+		state.admins = removeMembers(state.admins, []ID{owner})
+		// owner first
+		state.members = addMembers([]ID{owner}, state.members)
+
+	default:
+		return ErrGroupConflict
+	}
+	return nil
+}
+
+// replace members, only owner can remove administrators
+func (state *GroupState) reset(isOwner bool, members []ID) error {
+	if !containsMember(members, state.owner) {
+		// cannot remove the owner
+		return ErrGroupConflict
+	}
+	for _, item := range state.admins {
+		if !containsMember(members, item) && !isOwner {
+			return ErrGroupPermission
+		}
+	}
+	// owner first
+	state.members = addMembers([]ID{state.owner}, members)
+	state.admins = keepMembers(state.admins, state.members)
+	return nil
+}
+
+// order by (time, sender, sn)
+func compareGroupHistory(t1 float64, s1 string, sn1 SerialNumberType, t2 float64, s2 string, sn2 SerialNumberType) int {
+	if t1 != t2 {
+		if t1 < t2 {
+			return -1
+		}
+		return 1
+	} else if s1 != s2 {
+		if s1 < s2 {
+			return -1
+		}
+		return 1
+	} else if sn1 != sn2 {
+		if sn1 < sn2 {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func containsMember(array []ID, item ID) bool {
+	for _, member := range array {
+		if member.Equal(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// append new items to a copy of array
+func addMembers(array []ID, items []ID) []ID {
+	result := append([]ID{}, array...)
+	for _, item := range items {
+		if item != nil && !containsMember(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// remove items from a copy of array
+func removeMembers(array []ID, items []ID) []ID {
+	result := make([]ID, 0, len(array))
+	for _, member := range array {
+		if !containsMember(items, member) {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+// keep items in array which also in members
+func keepMembers(array []ID, members []ID) []ID {
+	result := make([]ID, 0, len(array))
+	for _, item := range array {
+		if containsMember(members, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
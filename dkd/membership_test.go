@@ -0,0 +1,190 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/dimchat/core-go/internal/testutil"
+
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+var (
+	group = testutil.IDFromString("group@Gh3Uz7HaGKC8xaHe5x3dzNgdTBX9AyDfN")
+	owner = testutil.IDFromString("alice@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk")
+	admin = testutil.IDFromString("bob@4YeVEN3aUnvC1DNUufCq1bs9zoBSJTzVEj")
+	carol = testutil.IDFromString("carol@4Qiq8CJ25Q6E3Kw9AfrhqPjSKJfVg3bDf1")
+	dave  = testutil.IDFromString("dave@4bSjb3XWKyxiQGKqMqmK5xrEvCV4iyVDM2")
+)
+
+const baseTime = 1700000000.0
+
+// set command time explicitly, so the order is fixed
+func at(cmd GroupCommand, offset float64) GroupCommand {
+	cmd.Set("time", baseTime+offset)
+	return cmd
+}
+
+func newTestGroupState() *GroupState {
+	state := NewGroupState(group, nil, nil)
+	state.SetFounderVerifier(func(g ID, founder ID) bool {
+		return g.Equal(group) && founder.Equal(owner)
+	})
+	return state
+}
+
+func sameMembers(a, b []ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGroupStateConverges(t *testing.T) {
+	history := []GroupHistory{
+		{Sender: owner, Command: at(NewFoundCommand(group, []ID{admin}), 1)},
+		{Sender: owner, Command: at(NewHireCommand(group, []ID{admin}, nil), 2)},
+		{Sender: admin, Command: at(NewInviteCommand(group, []ID{carol, dave}), 3)},
+		{Sender: dave, Command: at(NewQuitCommand(group), 4)},
+		{Sender: owner, Command: at(NewAbdicateCommand(group, carol), 5)},
+		{Sender: carol, Command: at(NewFireCommand(group, []ID{admin}, nil), 6)},
+		// rejected: bob is not an administrator any more
+		{Sender: admin, Command: at(NewInviteCommand(group, []ID{dave}), 7)},
+	}
+	expected := newTestGroupState()
+	rejected := expected.Replay(history)
+	if len(rejected) != 1 || !rejected[0].Sender.Equal(admin) {
+		t.Fatalf("rejected: %v", rejected)
+	} else if !expected.Owner().Equal(carol) {
+		t.Fatalf("owner: %v", expected.Owner())
+	} else if !sameMembers(expected.Members(), []ID{carol, owner, admin}) {
+		t.Fatalf("members: %v", expected.Members())
+	} else if len(expected.Administrators()) != 0 {
+		t.Fatalf("admins: %v", expected.Administrators())
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 20; i++ {
+		shuffled := append([]GroupHistory{}, history...)
+		rnd.Shuffle(len(shuffled), func(a, b int) {
+			shuffled[a], shuffled[b] = shuffled[b], shuffled[a]
+		})
+		state := newTestGroupState()
+		state.Replay(shuffled)
+		if !state.Owner().Equal(expected.Owner()) ||
+			!sameMembers(state.Members(), expected.Members()) ||
+			!sameMembers(state.Administrators(), expected.Administrators()) {
+			t.Errorf("round %d diverged: %v, %v", i, state.Owner(), state.Members())
+		}
+	}
+}
+
+func TestGroupStateRejections(t *testing.T) {
+	state := newTestGroupState()
+	if err := state.Apply(owner, at(NewFoundCommand(group, []ID{admin, carol}), 1)); err != nil {
+		t.Fatal(err)
+	} else if err = state.Apply(owner, at(NewHireCommand(group, []ID{admin}, nil), 2)); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name     string
+		sender   ID
+		cmd      GroupCommand
+		expected error
+	}{
+		{"non-admin invite", carol, NewInviteCommand(group, []ID{dave}), ErrGroupPermission},
+		{"admin hire", admin, NewHireCommand(group, []ID{carol}, nil), ErrGroupPermission},
+		{"owner quit", owner, NewQuitCommand(group), ErrGroupConflict},
+		{"abdicate to non-member", owner, NewAbdicateCommand(group, dave), ErrGroupConflict},
+		{"found again", owner, NewFoundCommand(group, nil), ErrGroupConflict},
+	}
+	for i, tc := range cases {
+		err := state.Apply(tc.sender, at(tc.cmd, float64(10+i)))
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.expected)
+		}
+	}
+	if !sameMembers(state.Members(), []ID{owner, admin, carol}) {
+		t.Errorf("members changed: %v", state.Members())
+	}
+}
+
+func TestGroupStateFounder(t *testing.T) {
+	// no verifier, no known owner
+	state := NewGroupState(group, nil, nil)
+	if err := state.Apply(owner, at(NewFoundCommand(group, nil), 1)); !errors.Is(err, ErrGroupPermission) {
+		t.Errorf("unverified founder should be rejected: %v", err)
+	}
+	// not the real founder
+	state = newTestGroupState()
+	if err := state.Apply(dave, at(NewFoundCommand(group, nil), 1)); !errors.Is(err, ErrGroupPermission) {
+		t.Errorf("fake founder should be rejected: %v", err)
+	}
+	// known owner
+	state = NewGroupState(group, owner, nil)
+	if err := state.Apply(dave, at(NewFoundCommand(group, nil), 1)); !errors.Is(err, ErrGroupPermission) {
+		t.Errorf("fake founder should be rejected: %v", err)
+	} else if err = state.Apply(owner, at(NewFoundCommand(group, []ID{carol}), 2)); err != nil {
+		t.Errorf("known owner should found the group: %v", err)
+	} else if !sameMembers(state.Members(), []ID{owner, carol}) {
+		t.Errorf("members: %v", state.Members())
+	}
+}
+
+func TestGroupStateTimeBound(t *testing.T) {
+	state := newTestGroupState()
+	state.SetTimeChecker(NewGroupTimeBound(time.Minute, 5*time.Second))
+	found := at(NewFoundCommand(group, []ID{admin}), 1)
+	if err := state.ApplyHistory(GroupHistory{Sender: owner, Command: found}); !errors.Is(err, ErrGroupTime) {
+		t.Errorf("command without message time should be rejected: %v", err)
+	} else if err = state.ApplyHistory(GroupHistory{Sender: owner, Command: found, Time: baseTime + 3600}); !errors.Is(err, ErrGroupTime) {
+		t.Errorf("backdated command should be rejected: %v", err)
+	} else if err = state.ApplyHistory(GroupHistory{Sender: owner, Command: found, Time: baseTime - 60}); !errors.Is(err, ErrGroupTime) {
+		t.Errorf("future command should be rejected: %v", err)
+	} else if err = state.ApplyHistory(GroupHistory{Sender: owner, Command: found, Time: baseTime + 2}); err != nil {
+		t.Errorf("command in time should be accepted: %v", err)
+	}
+}
@@ -87,7 +87,6 @@ func (content *BaseQuoteContent) Origin() StringKeyMap {
 		return dict
 	}
 	panic(fmt.Sprintf("Invalid origin: %v", origin))
-	return nil
 }
 
 // Override
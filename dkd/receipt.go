@@ -90,7 +90,6 @@ func (content *BaseReceiptCommand) Origin() StringKeyMap {
 		return dict
 	}
 	panic(fmt.Sprintf("Invalid origin: %v", origin))
-	return nil
 }
 
 // Override
@@ -0,0 +1,139 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Streaming Upload/Download
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  For large files (video, ...), the file body will not be loaded into
+ *  'content.data', instead it is encrypted chunk by chunk while uploading,
+ *  and the file content only carries the download URL & decrypt key,
+ *  with "format" to tell the receiver how to decrypt the blob:
+ *
+ *      "DIMF/1" - chunked stream (see FileEncrypter);
+ *      absent   - the whole blob is encrypted at once (legacy clients).
+ */
+
+// FileUploader uploads the encrypted blob to CDN
+type FileUploader interface {
+
+	// UploadFile reads the blob until EOF and uploads it
+	//
+	// Parameters:
+	//   - blob     : encrypted file body
+	//   - filename : original filename
+	//
+	// Returns: download URL
+	UploadFile(blob io.Reader, filename string) (URL, error)
+}
+
+// UploadFileContent encrypts the file body with password while uploading,
+// then updates the file content with the download URL & password
+//
+// Parameters:
+//   - content  : file content (the 'data' will be removed)
+//   - body     : file body
+//   - password : symmetric key to encrypt the file body
+//   - uploader : CDN uploader
+//
+// Returns: PNF with the download URL & decrypt key
+func UploadFileContent(content FileContent, body io.Reader, password SymmetricKey, uploader FileUploader) (TransportableFile, error) {
+	filename := content.Filename()
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := EncryptFileStream(writer, body, password)
+		writer.CloseWithError(err)
+	}()
+	url, err := uploader.UploadFile(reader, filename)
+	// stop the encrypting goroutine if the uploader did not read to the end
+	reader.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return nil, err
+	} else if url == nil {
+		return nil, errors.New("failed to upload file: " + filename)
+	}
+	content.SetData(nil)
+	content.SetURL(url)
+	content.SetPassword(password)
+	content.Set("format", FILE_STREAM_FORMAT)
+	pnf := NewPortableNetworkFile(nil, nil, filename, url, password)
+	pnf.Set("format", FILE_STREAM_FORMAT)
+	return pnf, nil
+}
+
+// DecryptFileContent decrypts the blob downloaded from 'content.URL'
+// with 'content.password', and writes the file body to dst
+//
+// The blob is decrypted by 'content.format', blob without format is
+// taken as encrypted at once by legacy clients.
+//
+// Returns: size of the file body
+func DecryptFileContent(content FileContent, blob io.Reader, dst io.Writer) (int64, error) {
+	password := content.Password()
+	if password == nil {
+		return 0, errors.New("file password not found: " + content.Filename())
+	}
+	format := content.GetString("format", "")
+	switch format {
+	case FILE_STREAM_FORMAT:
+		return DecryptFileStream(dst, blob, password)
+	case "":
+		return decryptFileBlob(content, blob, dst, password)
+	default:
+		return 0, fmt.Errorf("%w: unknown format: %s", ErrFileStream, format)
+	}
+}
+
+// legacy: the whole file body was encrypted with the params in content
+func decryptFileBlob(content FileContent, blob io.Reader, dst io.Writer, password DecryptKey) (int64, error) {
+	ciphertext, err := io.ReadAll(blob)
+	if err != nil {
+		return 0, err
+	}
+	plaintext := password.Decrypt(ciphertext, content.Map())
+	if len(plaintext) == 0 {
+		return 0, fmt.Errorf("%w: failed to decrypt file: %s", ErrFileStream, content.Filename())
+	}
+	n, err := dst.Write(plaintext)
+	return int64(n), err
+}
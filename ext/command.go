@@ -30,7 +30,10 @@
  */
 package ext
 
-import . "github.com/dimchat/mkm-go/types"
+import (
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
 
 /**
  *  Command GeneralFactory
@@ -51,6 +54,15 @@ func SetGeneralCommandHelper(helper GeneralCommandHelper) {
 	sharedGeneralCommandHelper = helper
 }
 
+// GetGeneralCommandHelper returns the helper set by SetGeneralCommandHelper,
+// or the current command helper if it also knows how to get the command name
+//
+// The command helper is resolved on each call, so it won't go stale after
+// SetCommandHelper() or SetDefaultContext()
 func GetGeneralCommandHelper() GeneralCommandHelper {
-	return sharedGeneralCommandHelper
+	if helper := sharedGeneralCommandHelper; helper != nil {
+		return helper
+	}
+	helper, _ := GetCommandHelper().(GeneralCommandHelper)
+	return helper
 }
@@ -39,6 +39,9 @@ import . "github.com/dimchat/mkm-go/format"
 type Base64Data struct {
 	//TransportableData
 	*BaseData
+
+	// context provides the base64 coder (nil means the global coder)
+	context *FormatContext
 }
 
 func NewBase64Data(encoded string, bytes []byte) *Base64Data {
@@ -47,6 +50,15 @@ func NewBase64Data(encoded string, bytes []byte) *Base64Data {
 	}
 }
 
+// protected
+func (ted *Base64Data) DataCoder() DataCoder {
+	ctx := ted.context
+	if ctx == nil {
+		return nil
+	}
+	return ctx.GetDataCoder(BASE_64)
+}
+
 //
 //  TransportableData
 //
@@ -60,7 +72,11 @@ func (ted *Base64Data) Encoding() string {
 func (ted *Base64Data) Bytes() []byte {
 	bin := ted.bytes
 	if bin == nil {
-		bin = Base64Decode(ted.encoded)
+		if coder := ted.DataCoder(); coder != nil {
+			bin = coder.Decode(ted.encoded)
+		} else {
+			bin = Base64Decode(ted.encoded)
+		}
 		ted.bytes = bin
 	}
 	return bin
@@ -70,7 +86,11 @@ func (ted *Base64Data) Bytes() []byte {
 func (ted *Base64Data) String() string {
 	base64 := ted.encoded
 	if base64 == "" {
-		base64 = Base64Encode(ted.bytes)
+		if coder := ted.DataCoder(); coder != nil {
+			base64 = coder.Encode(ted.bytes)
+		} else {
+			base64 = Base64Encode(ted.bytes)
+		}
 		ted.encoded = base64
 	}
 	return base64
@@ -0,0 +1,259 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package format
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Canonical JsON
+ *  ~~~~~~~~~~~~~~
+ *  Deterministic serialization for signed/digested payloads,
+ *  follows the JSON Canonicalization Scheme (RFC 8785):
+ *
+ *      1. object members are sorted by key;
+ *      2. no whitespace between tokens;
+ *      3. numbers are formatted as ECMAScript does
+ *         (integers without exponent, shortest round-trip form),
+ *         integers are converted to double first, so the ones
+ *         beyond 2^53 may lose precision as in JavaScript;
+ *      4. strings only escape '"', '\\' and control characters,
+ *         no HTML escaping ('<', '>', '&' are kept as they are).
+ *
+ *  So the same data signed on any platform will produce the same text.
+ */
+
+// CanonicalJSONEncode converts a Map or List object to canonical JsON string
+func CanonicalJSONEncode(object any) string {
+	var sb strings.Builder
+	writeCanonical(&sb, object)
+	return sb.String()
+}
+
+func CanonicalJSONEncodeMap(dict StringKeyMap) string {
+	return CanonicalJSONEncode(dict)
+}
+
+func writeCanonical(sb *strings.Builder, value any) {
+	switch v := value.(type) {
+	case nil:
+		sb.WriteString("null")
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case int:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case int8:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case int16:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case int32:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case int64:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case uint:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case uint8:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case uint16:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case uint32:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case uint64:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case float32:
+		sb.WriteString(canonicalNumber(float64(v)))
+	case float64:
+		sb.WriteString(canonicalNumber(v))
+	case string:
+		writeCanonicalString(sb, v)
+	case []byte:
+		writeCanonicalString(sb, Base64Encode(v))
+	case StringKeyMap:
+		writeCanonicalMap(sb, v)
+	case []any:
+		writeCanonicalList(sb, v)
+	case Mapper:
+		writeCanonicalMap(sb, v.Map())
+	case Time:
+		sb.WriteString(canonicalNumber(TimeToFloat64(v)))
+	case fmt.Stringer:
+		writeCanonicalString(sb, v.String())
+	default:
+		// other types
+		writeCanonicalReflect(sb, reflect.ValueOf(value))
+	}
+}
+
+func writeCanonicalReflect(sb *strings.Builder, rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			sb.WriteString("null")
+		} else {
+			writeCanonical(sb, rv.Elem().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		count := rv.Len()
+		array := make([]any, count)
+		for i := 0; i < count; i++ {
+			array[i] = rv.Index(i).Interface()
+		}
+		writeCanonicalList(sb, array)
+	case reflect.Map:
+		dict := StringKeyMap{}
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprintf("%v", iter.Key().Interface())
+			dict[key] = iter.Value().Interface()
+		}
+		writeCanonicalMap(sb, dict)
+	case reflect.String:
+		writeCanonicalString(sb, rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sb.WriteString(canonicalNumber(float64(rv.Int())))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		sb.WriteString(canonicalNumber(float64(rv.Uint())))
+	case reflect.Float32, reflect.Float64:
+		sb.WriteString(canonicalNumber(rv.Float()))
+	default:
+		// unknown type
+		writeCanonicalString(sb, fmt.Sprintf("%v", rv.Interface()))
+	}
+}
+
+func writeCanonicalMap(sb *strings.Builder, dict StringKeyMap) {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	// RFC 8785 sorts by UTF-16 code units
+	sort.Slice(keys, func(i, j int) bool {
+		return lessUTF16(keys[i], keys[j])
+	})
+	sb.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		writeCanonicalString(sb, key)
+		sb.WriteByte(':')
+		writeCanonical(sb, dict[key])
+	}
+	sb.WriteByte('}')
+}
+
+func writeCanonicalList(sb *strings.Builder, array []any) {
+	sb.WriteByte('[')
+	for i, item := range array {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		writeCanonical(sb, item)
+	}
+	sb.WriteByte(']')
+}
+
+const hexDigits = "0123456789abcdef"
+
+func writeCanonicalString(sb *strings.Builder, str string) {
+	sb.WriteByte('"')
+	for _, r := range str {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				sb.WriteString(`\u00`)
+				sb.WriteByte(hexDigits[r>>4])
+				sb.WriteByte(hexDigits[r&0xf])
+			} else {
+				// invalid UTF-8 bytes will be written as U+FFFD
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+}
+
+// canonicalNumber formats a number as ECMAScript Number.prototype.toString()
+func canonicalNumber(f float64) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		// not allowed in JsON
+		return "null"
+	} else if f == 0 {
+		// -0 => 0
+		return "0"
+	}
+	abs := math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	// exponent form: "1e+21", "1.5e-7"
+	text := strconv.FormatFloat(f, 'e', -1, 64)
+	pos := strings.IndexByte(text, 'e')
+	mantissa, exponent := text[:pos], text[pos+2:]
+	sign := text[pos+1]
+	exponent = strings.TrimLeft(exponent, "0")
+	return mantissa + "e" + string(sign) + exponent
+}
+
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
@@ -0,0 +1,153 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package format_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// RFC 8785, Appendix B: IEEE 754 bits => ECMAScript string
+var canonicalNumberVectors = []struct {
+	bits     uint64
+	expected string
+}{
+	{0x0000000000000000, "0"},
+	{0x8000000000000000, "0"},
+	{0x0000000000000001, "5e-324"},
+	{0x8000000000000001, "-5e-324"},
+	{0x7fefffffffffffff, "1.7976931348623157e+308"},
+	{0xffefffffffffffff, "-1.7976931348623157e+308"},
+	{0x4340000000000000, "9007199254740992"},
+	{0xc340000000000000, "-9007199254740992"},
+	{0x4430000000000000, "295147905179352830000"},
+	{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+	{0x44b52d02c7e14af6, "1e+23"},
+	{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+	{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+	{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+	{0x444b1ae4d6e2ef50, "1e+21"},
+	{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+	{0x3eb0c6f7a0b5ed8d, "0.000001"},
+	{0x41b3de4355555553, "333333333.3333332"},
+	{0x41b3de4355555554, "333333333.33333325"},
+	{0x41b3de4355555555, "333333333.3333333"},
+	{0x41b3de4355555556, "333333333.3333334"},
+	{0x41b3de4355555557, "333333333.33333343"},
+	{0xbecbf647612f3696, "-0.0000033333333333333333"},
+	{0x43143ff3c1cb0959, "1424953923781206.2"},
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	for _, tc := range canonicalNumberVectors {
+		f := math.Float64frombits(tc.bits)
+		if text := CanonicalJSONEncode([]any{f}); text != "["+tc.expected+"]" {
+			t.Errorf("%016x: got %s, want [%s]", tc.bits, text, tc.expected)
+		}
+	}
+}
+
+// same results as JavaScript JSON.stringify(), Java (JCS) & Python (jcs)
+func TestCanonicalValues(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{1.5e-7, "1.5e-7"},
+		{1e21, "1e+21"},
+		{1e20, "100000000000000000000"},
+		{123.0, "123"},
+		{-0.5, "-0.5"},
+		{int64(-9007199254740993), "-9007199254740992"},
+		{uint64(math.MaxUint64), "18446744073709552000"},
+		{42, "42"},
+		{int8(-8), "-8"},
+		{float32(0.1), "0.10000000149011612"},
+		{math.NaN(), "null"},
+		{math.Inf(1), "null"},
+		{true, "true"},
+		{nil, "null"},
+		{"<a href='x'>&</a>", `"<a href='x'>&</a>"`},
+		{"€$\u000f\nA'B\"\\\\\"/", `"€$\u000f\nA'B\"\\\\\"/"`},
+		{"\u007f ", "\"\u007f \""},
+	}
+	for _, tc := range cases {
+		text := CanonicalJSONEncode([]any{tc.value})
+		if text != "["+tc.expected+"]" {
+			t.Errorf("%#v: got %s, want [%s]", tc.value, text, tc.expected)
+		}
+	}
+}
+
+// RFC 8785, 3.2.3: keys are sorted by UTF-16 code units
+func TestCanonicalKeySorting(t *testing.T) {
+	dict := StringKeyMap{
+		"\u20ac":     "Euro Sign",
+		"\r":         "Carriage Return",
+		"\ufb33":     "Hebrew Letter Dalet With Dagesh",
+		"1":          "One",
+		"\U0001F600": "Emoji: Grinning Face",
+		"\u0080":     "Control",
+		"\u00f6":     "Latin Small Letter O With Diaeresis",
+	}
+	expected := `{"\r":"Carriage Return","1":"One",` +
+		"\"\u0080\":\"Control\"," +
+		"\"\u00f6\":\"Latin Small Letter O With Diaeresis\"," +
+		"\"\u20ac\":\"Euro Sign\"," +
+		"\"\U0001F600\":\"Emoji: Grinning Face\"," +
+		"\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+	if text := CanonicalJSONEncodeMap(dict); text != expected {
+		t.Errorf("got: %s\nwant: %s", text, expected)
+	}
+}
+
+// RFC 8785, 3.2.4: nested objects & arrays, no whitespace
+func TestCanonicalNested(t *testing.T) {
+	dict := StringKeyMap{
+		"numbers":  []any{333333333.33333329, 1e30, 4.50, 2e-3, 0.000000000000000000000000001},
+		"string":   "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"/",
+		"literals": []any{nil, true, false},
+		"nested": map[string]any{
+			"b": []int{3, 2, 1},
+			"a": StringKeyMap{"z": 1, "y": "x"},
+		},
+	}
+	expected := `{"literals":[null,true,false],` +
+		`"nested":{"a":{"y":"x","z":1},"b":[3,2,1]},` +
+		`"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+		`"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	if text := CanonicalJSONEncodeMap(dict); text != expected {
+		t.Errorf("got: %s\nwant: %s", text, expected)
+	}
+}
@@ -32,14 +32,14 @@ package format
 
 import . "github.com/dimchat/mkm-go/format"
 
-var sharedDataCoders = make(map[string]DataCoder, 8)
-
 func SetDataCoder(name string, coder DataCoder) {
-	sharedDataCoders[name] = coder
+	ctx := GetFormatContext()
+	ctx.SetDataCoder(name, coder)
 }
 
 func GetDataCoder(encoding string) DataCoder {
-	return sharedDataCoders[encoding]
+	ctx := GetFormatContext()
+	return ctx.GetDataCoder(encoding)
 }
 
 //
@@ -59,8 +59,3 @@ func (base64Coder) Encode(data []byte) string {
 func (base64Coder) Decode(data string) []byte {
 	return Base64Decode(data)
 }
-
-func init() {
-	defaultCoder := &base64Coder{}
-	SetDataCoder(BASE_64, defaultCoder)
-}
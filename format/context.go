@@ -0,0 +1,273 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package format
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/dimchat/core-go/rfc"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Format Context
+ */
+
+// FormatContext carries the data coders and the PNF wrapper factory
+//
+// The package-level functions (SetDataCoder, GetDataCoder,
+// CreateTransportableFileWrapper, ...) work with the default context;
+// create a new context if different coders or factories are needed
+// in the same process.
+//
+// All registries are protected by a read-write lock, so it's safe to
+// register coders while parsing data on other goroutines; after Freeze()
+// is called, any further registration will be ignored.
+type FormatContext struct {
+	mutex  sync.RWMutex
+	frozen bool
+
+	// dataCoders maps the encoding name ("base64", ...) to its coder
+	dataCoders map[string]DataCoder
+
+	// wrapperFactory creates wrappers for PNF & file contents
+	wrapperFactory TransportableFileWrapperFactory
+}
+
+func NewFormatContext() *FormatContext {
+	ctx := &FormatContext{
+		dataCoders: make(map[string]DataCoder, 8),
+	}
+	ctx.wrapperFactory = &pnfWrapperFactory{
+		context: ctx,
+	}
+	// default coder
+	ctx.SetDataCoder(BASE_64, &base64Coder{})
+	return ctx
+}
+
+var sharedFormatContext atomic.Value // *FormatContext
+
+// SetFormatContext replaces the default format context (nil is ignored)
+func SetFormatContext(ctx *FormatContext) {
+	if ctx == nil {
+		return
+	}
+	sharedFormatContext.Store(ctx)
+}
+
+func GetFormatContext() *FormatContext {
+	return sharedFormatContext.Load().(*FormatContext)
+}
+
+func init() {
+	SetFormatContext(NewFormatContext())
+}
+
+// Freeze stops accepting registrations,
+// call it after all coders & factories are set;
+// any further registration will be ignored (the setters return false)
+func (ctx *FormatContext) Freeze() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	ctx.frozen = true
+}
+
+func (ctx *FormatContext) IsFrozen() bool {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+	return ctx.frozen
+}
+
+//
+//  Data Coders
+//
+
+// SetDataCoder registers coder for the encoding name
+//
+// Returns: false if the context is frozen
+func (ctx *FormatContext) SetDataCoder(name string, coder DataCoder) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	if ctx.frozen {
+		return false
+	}
+	ctx.dataCoders[name] = coder
+	return true
+}
+
+func (ctx *FormatContext) GetDataCoder(encoding string) DataCoder {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+	return ctx.dataCoders[encoding]
+}
+
+//
+//  PNF Wrapper Factory
+//
+
+// SetTransportableFileWrapperFactory replaces the wrapper factory
+//
+// Returns: false if the context is frozen
+func (ctx *FormatContext) SetTransportableFileWrapperFactory(factory TransportableFileWrapperFactory) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	if ctx.frozen {
+		return false
+	}
+	ctx.wrapperFactory = factory
+	return true
+}
+
+func (ctx *FormatContext) GetTransportableFileWrapperFactory() TransportableFileWrapperFactory {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+	return ctx.wrapperFactory
+}
+
+func (ctx *FormatContext) CreateTransportableFileWrapper(content StringKeyMap,
+	data TransportableData, filename string, url URL, password DecryptKey,
+) TransportableFileWrapper {
+	factory := ctx.GetTransportableFileWrapperFactory()
+	return factory.CreateTransportableFileWrapper(content, data, filename, url, password)
+}
+
+//
+//  Creations
+//
+
+// NewEmbedDataWithType creates embed data using the coders of this context
+func (ctx *FormatContext) NewEmbedDataWithType(mimeType string, body []byte) TransportableData {
+	head := NewDataHeader(mimeType, BASE_64, nil)
+	ted := NewEmbedData("", body, nil, head)
+	ted.context = ctx
+	return ted
+}
+
+// NewEmbedDataWithURI creates embed data using the coders of this context
+func (ctx *FormatContext) NewEmbedDataWithURI(uri DataURI) TransportableData {
+	head := uri.Head()
+	ted := NewEmbedData("", nil, uri, head)
+	ted.context = ctx
+	return ted
+}
+
+// ParseEmbedData parses data URI with the coders of this context,
+// returns nil if the text is not a data URI
+func (ctx *FormatContext) ParseEmbedData(text string) TransportableData {
+	uri := ParseDataURI(text)
+	if uri == nil {
+		return nil
+	}
+	return ctx.NewEmbedDataWithURI(uri)
+}
+
+// ParseTransportableData parses TED (data URI or base64 string)
+// with the coders of this context
+func (ctx *FormatContext) ParseTransportableData(ted any) TransportableData {
+	if ValueIsNil(ted) {
+		return nil
+	} else if data, ok := ted.(TransportableData); ok {
+		return data
+	}
+	text := ConvertString(ted, "")
+	if text == "" {
+		return nil
+	} else if data := ctx.ParseEmbedData(text); data != nil {
+		return data
+	}
+	data := NewBase64Data(text, nil)
+	data.context = ctx
+	return data
+}
+
+// ParsePortableNetworkFile parses PNF (map, JSON, data URI or URL string)
+// with the wrapper factory of this context
+func (ctx *FormatContext) ParsePortableNetworkFile(pnf any) TransportableFile {
+	if ValueIsNil(pnf) {
+		return nil
+	} else if file, ok := pnf.(TransportableFile); ok {
+		return file
+	}
+	info := FetchMap(pnf)
+	if info == nil {
+		text := ConvertString(pnf, "")
+		if text == "" {
+			return nil
+		} else if strings.HasPrefix(text, "{") {
+			info = JSONDecodeMap(text)
+		} else if ParseDataURI(text) != nil {
+			info = StringKeyMap{"data": text}
+		} else {
+			info = StringKeyMap{"URL": text}
+		}
+		if info == nil {
+			return nil
+		}
+	}
+	return ctx.NewPortableNetworkFileWithMap(info)
+}
+
+// NewPortableNetworkFileWithMap creates PNF using the wrapper factory of this context
+func (ctx *FormatContext) NewPortableNetworkFileWithMap(dict StringKeyMap) TransportableFile {
+	return ctx.NewPortableNetworkFile(dict, nil, "", nil, nil)
+}
+
+// NewPortableNetworkFileWithData creates PNF using the wrapper factory of this context
+func (ctx *FormatContext) NewPortableNetworkFileWithData(data TransportableData, filename string,
+	url URL, password DecryptKey,
+) TransportableFile {
+	return ctx.NewPortableNetworkFile(nil, data, filename, url, password)
+}
+
+func (ctx *FormatContext) NewPortableNetworkFile(dict StringKeyMap,
+	data TransportableData, filename string,
+	url URL, password DecryptKey,
+) *PortableNetworkFile {
+	if dict != nil {
+		// init PNF with map
+		return &PortableNetworkFile{
+			Dictionary: NewDictionary(dict),
+			wrapper:    ctx.CreateTransportableFileWrapper(dict, nil, "", nil, nil),
+		}
+	}
+	// new PNF
+	pnf := &PortableNetworkFile{
+		Dictionary: NewDictionary(nil),
+	}
+	pnf.wrapper = ctx.CreateTransportableFileWrapper(pnf.Dictionary.Map(), data, filename, url, password)
+	return pnf
+}
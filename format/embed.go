@@ -43,6 +43,9 @@ type EmbedData struct {
 
 	dataURI  DataURI
 	dataHead DataHeader
+
+	// context provides the data coders (nil means the default context)
+	context *FormatContext
 }
 
 func NewEmbedData(encoded string, bytes []byte, uri DataURI, head DataHeader) *EmbedData {
@@ -56,7 +59,11 @@ func NewEmbedData(encoded string, bytes []byte, uri DataURI, head DataHeader) *E
 // protected
 func (ted *EmbedData) DataCoder() DataCoder {
 	encoding := ted.Encoding()
-	return GetDataCoder(encoding)
+	ctx := ted.context
+	if ctx == nil {
+		ctx = GetFormatContext()
+	}
+	return ctx.GetDataCoder(encoding)
 }
 
 // protected
@@ -92,7 +99,6 @@ func (ted *EmbedData) Bytes() []byte {
 		uri := ted.dataURI
 		if uri == nil || uri.IsEmpty() {
 			panic(fmt.Sprintf("data URI error: %v", uri))
-			return nil
 		}
 		coder := ted.DataCoder()
 		base64 := uri.Body()
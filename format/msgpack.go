@@ -0,0 +1,507 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package format
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// MessagePackCoder serializes Map/List trees into MessagePack binary form
+//
+// Binary values ([]byte) are kept as raw 'bin' objects, and map keys are
+// sorted, so the same tree always encodes to the same bytes.
+//
+//	Supported types:
+//	    nil, bool, integers, floats, string, []byte,
+//	    Map (string keys), List, Mapper, Stringer, Time
+type MessagePackCoder struct {
+	//ObjectCoder (binary)
+}
+
+var ErrMessagePack = errors.New("msgpack error")
+
+// Encode converts a Map or List object to MessagePack binary data
+func (MessagePackCoder) Encode(object any) []byte {
+	enc := &msgpackEncoder{}
+	enc.encode(object)
+	return enc.buf
+}
+
+// Decode parses MessagePack binary data back to a Map or List object
+//
+// Returns: nil on error
+func (coder MessagePackCoder) Decode(data []byte) any {
+	object, err := coder.DecodeObject(data)
+	if err != nil {
+		//panic(err)
+		return nil
+	}
+	return object
+}
+
+// DecodeObject parses MessagePack binary data with error detail
+func (MessagePackCoder) DecodeObject(data []byte) (any, error) {
+	dec := &msgpackDecoder{buf: data}
+	object, err := dec.decode(0)
+	if err != nil {
+		return nil, err
+	} else if dec.pos != len(data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMessagePack, len(data)-dec.pos)
+	}
+	return object, nil
+}
+
+//
+//  MessagePack <-> Map
+//
+
+var msgpackCoder = MessagePackCoder{}
+
+func MsgPackEncodeMap(dict StringKeyMap) []byte {
+	return msgpackCoder.Encode(dict)
+}
+
+func MsgPackDecodeMap(data []byte) StringKeyMap {
+	dict := msgpackCoder.Decode(data)
+	return FetchMap(dict)
+}
+
+//
+//  Encoder
+//
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (enc *msgpackEncoder) put(b ...byte) {
+	enc.buf = append(enc.buf, b...)
+}
+
+func (enc *msgpackEncoder) putUint(head byte, v uint64, size int) {
+	var scratch [8]byte
+	enc.put(head)
+	switch size {
+	case 1:
+		enc.put(byte(v))
+	case 2:
+		binary.BigEndian.PutUint16(scratch[:2], uint16(v))
+		enc.put(scratch[:2]...)
+	case 4:
+		binary.BigEndian.PutUint32(scratch[:4], uint32(v))
+		enc.put(scratch[:4]...)
+	case 8:
+		binary.BigEndian.PutUint64(scratch[:8], v)
+		enc.put(scratch[:8]...)
+	}
+}
+
+func (enc *msgpackEncoder) encodeInt(v int64) {
+	if v >= 0 {
+		enc.encodeUint(uint64(v))
+	} else if v >= -32 {
+		enc.put(byte(v))
+	} else if v >= math.MinInt8 {
+		enc.putUint(0xd0, uint64(v), 1)
+	} else if v >= math.MinInt16 {
+		enc.putUint(0xd1, uint64(v), 2)
+	} else if v >= math.MinInt32 {
+		enc.putUint(0xd2, uint64(v), 4)
+	} else {
+		enc.putUint(0xd3, uint64(v), 8)
+	}
+}
+
+func (enc *msgpackEncoder) encodeUint(v uint64) {
+	if v <= 0x7f {
+		enc.put(byte(v))
+	} else if v <= math.MaxUint8 {
+		enc.putUint(0xcc, v, 1)
+	} else if v <= math.MaxUint16 {
+		enc.putUint(0xcd, v, 2)
+	} else if v <= math.MaxUint32 {
+		enc.putUint(0xce, v, 4)
+	} else {
+		enc.putUint(0xcf, v, 8)
+	}
+}
+
+func (enc *msgpackEncoder) encodeFloat(v float64) {
+	// integral numbers (e.g.: decoded from JsON) are packed as integers
+	if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+		enc.encodeInt(int64(v))
+		return
+	}
+	enc.putUint(0xcb, math.Float64bits(v), 8)
+}
+
+func (enc *msgpackEncoder) encodeString(s string) {
+	n := uint64(len(s))
+	if n <= 31 {
+		enc.put(0xa0 | byte(n))
+	} else if n <= math.MaxUint8 {
+		enc.putUint(0xd9, n, 1)
+	} else if n <= math.MaxUint16 {
+		enc.putUint(0xda, n, 2)
+	} else {
+		enc.putUint(0xdb, n, 4)
+	}
+	enc.buf = append(enc.buf, s...)
+}
+
+func (enc *msgpackEncoder) encodeBinary(b []byte) {
+	n := uint64(len(b))
+	if n <= math.MaxUint8 {
+		enc.putUint(0xc4, n, 1)
+	} else if n <= math.MaxUint16 {
+		enc.putUint(0xc5, n, 2)
+	} else {
+		enc.putUint(0xc6, n, 4)
+	}
+	enc.put(b...)
+}
+
+func (enc *msgpackEncoder) encodeArrayHead(n int) {
+	if n <= 15 {
+		enc.put(0x90 | byte(n))
+	} else if n <= math.MaxUint16 {
+		enc.putUint(0xdc, uint64(n), 2)
+	} else {
+		enc.putUint(0xdd, uint64(n), 4)
+	}
+}
+
+func (enc *msgpackEncoder) encodeMapHead(n int) {
+	if n <= 15 {
+		enc.put(0x80 | byte(n))
+	} else if n <= math.MaxUint16 {
+		enc.putUint(0xde, uint64(n), 2)
+	} else {
+		enc.putUint(0xdf, uint64(n), 4)
+	}
+}
+
+func (enc *msgpackEncoder) encodeMap(dict StringKeyMap) {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	enc.encodeMapHead(len(keys))
+	for _, key := range keys {
+		enc.encodeString(key)
+		enc.encode(dict[key])
+	}
+}
+
+func (enc *msgpackEncoder) encode(value any) {
+	switch v := value.(type) {
+	case nil:
+		enc.put(0xc0)
+	case bool:
+		if v {
+			enc.put(0xc3)
+		} else {
+			enc.put(0xc2)
+		}
+	case int:
+		enc.encodeInt(int64(v))
+	case int8:
+		enc.encodeInt(int64(v))
+	case int16:
+		enc.encodeInt(int64(v))
+	case int32:
+		enc.encodeInt(int64(v))
+	case int64:
+		enc.encodeInt(v)
+	case uint:
+		enc.encodeUint(uint64(v))
+	case uint8:
+		enc.encodeUint(uint64(v))
+	case uint16:
+		enc.encodeUint(uint64(v))
+	case uint32:
+		enc.encodeUint(uint64(v))
+	case uint64:
+		enc.encodeUint(v)
+	case float32:
+		enc.encodeFloat(float64(v))
+	case float64:
+		enc.encodeFloat(v)
+	case string:
+		enc.encodeString(v)
+	case []byte:
+		enc.encodeBinary(v)
+	case StringKeyMap:
+		enc.encodeMap(v)
+	case []any:
+		enc.encodeArrayHead(len(v))
+		for _, item := range v {
+			enc.encode(item)
+		}
+	case Mapper:
+		enc.encodeMap(v.Map())
+	case Time:
+		enc.encodeFloat(TimeToFloat64(v))
+	case fmt.Stringer:
+		enc.encodeString(v.String())
+	default:
+		enc.encodeReflect(reflect.ValueOf(value))
+	}
+}
+
+func (enc *msgpackEncoder) encodeReflect(rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			enc.put(0xc0)
+		} else {
+			enc.encode(rv.Elem().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		count := rv.Len()
+		enc.encodeArrayHead(count)
+		for i := 0; i < count; i++ {
+			enc.encode(rv.Index(i).Interface())
+		}
+	case reflect.Map:
+		dict := StringKeyMap{}
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprintf("%v", iter.Key().Interface())
+			dict[key] = iter.Value().Interface()
+		}
+		enc.encodeMap(dict)
+	default:
+		// unknown type
+		enc.encodeString(fmt.Sprintf("%v", rv.Interface()))
+	}
+}
+
+//
+//  Decoder
+//
+
+// max depth of nested containers
+const msgpackMaxDepth = 64
+
+type msgpackDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (dec *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || dec.pos+n > len(dec.buf) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrMessagePack)
+	}
+	b := dec.buf[dec.pos : dec.pos+n]
+	dec.pos += n
+	return b, nil
+}
+
+func (dec *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := dec.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (dec *msgpackDecoder) readLength(size int) (int, error) {
+	n, err := dec.readUint(size)
+	if err != nil {
+		return 0, err
+	} else if n > uint64(len(dec.buf)-dec.pos) {
+		// each item takes one byte at least
+		return 0, fmt.Errorf("%w: length %d out of range", ErrMessagePack, n)
+	}
+	return int(n), nil
+}
+
+func (dec *msgpackDecoder) readString(n int) (string, error) {
+	b, err := dec.read(n)
+	return string(b), err
+}
+
+func (dec *msgpackDecoder) readBinary(n int) ([]byte, error) {
+	b, err := dec.read(n)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	copy(data, b)
+	return data, nil
+}
+
+func (dec *msgpackDecoder) readArray(n, depth int) ([]any, error) {
+	array := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+	return array, nil
+}
+
+func (dec *msgpackDecoder) readMap(n, depth int) (StringKeyMap, error) {
+	dict := make(StringKeyMap, n)
+	for i := 0; i < n; i++ {
+		key, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case string:
+			dict[k] = value
+		case []byte:
+			dict[string(k)] = value
+		default:
+			dict[fmt.Sprintf("%v", k)] = value
+		}
+	}
+	return dict, nil
+}
+
+func (dec *msgpackDecoder) decode(depth int) (any, error) {
+	if depth > msgpackMaxDepth {
+		return nil, fmt.Errorf("%w: nested too deep", ErrMessagePack)
+	}
+	head, err := dec.read(1)
+	if err != nil {
+		return nil, err
+	}
+	b := head[0]
+	switch {
+	case b <= 0x7f:
+		// positive fixint
+		return int64(b), nil
+	case b >= 0xe0:
+		// negative fixint
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return dec.readMap(int(b&0x0f), depth)
+	case b&0xf0 == 0x90:
+		return dec.readArray(int(b&0x0f), depth)
+	case b&0xe0 == 0xa0:
+		return dec.readString(int(b & 0x1f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	// binary
+	case 0xc4, 0xc5, 0xc6:
+		n, err := dec.readLength(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readBinary(n)
+	// float
+	case 0xca:
+		v, err := dec.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := dec.readUint(8)
+		return math.Float64frombits(v), err
+	// unsigned integer
+	case 0xcc, 0xcd, 0xce:
+		v, err := dec.readUint(1 << (b - 0xcc))
+		return int64(v), err
+	case 0xcf:
+		v, err := dec.readUint(8)
+		if err != nil {
+			return nil, err
+		} else if v > math.MaxInt64 {
+			return v, nil
+		}
+		return int64(v), nil
+	// signed integer
+	case 0xd0:
+		v, err := dec.readUint(1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := dec.readUint(2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := dec.readUint(4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := dec.readUint(8)
+		return int64(v), err
+	// string
+	case 0xd9, 0xda, 0xdb:
+		n, err := dec.readLength(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readString(n)
+	// array
+	case 0xdc, 0xdd:
+		n, err := dec.readLength(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readArray(n, depth)
+	// map
+	case 0xde, 0xdf:
+		n, err := dec.readLength(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readMap(n, depth)
+	}
+	return nil, fmt.Errorf("%w: unsupported type 0x%02x", ErrMessagePack, b)
+}
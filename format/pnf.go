@@ -48,19 +48,8 @@ func NewPortableNetworkFile(dict StringKeyMap,
 	data TransportableData, filename string,
 	url URL, password DecryptKey,
 ) *PortableNetworkFile {
-	if dict != nil {
-		// init PNF with map
-		return &PortableNetworkFile{
-			Dictionary: NewDictionary(dict),
-			wrapper:    CreateTransportableFileWrapper(dict, nil, "", nil, nil),
-		}
-	}
-	// new PNF
-	pnf := &PortableNetworkFile{
-		Dictionary: NewDictionary(nil),
-	}
-	pnf.wrapper = CreateTransportableFileWrapper(pnf.Map(), data, filename, url, password)
-	return pnf
+	ctx := GetFormatContext()
+	return ctx.NewPortableNetworkFile(dict, data, filename, url, password)
 }
 
 func (pnf *PortableNetworkFile) getURIString() string {
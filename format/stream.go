@@ -0,0 +1,314 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package format
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Chunked File Encryption
+ *  ~~~~~~~~~~~~~~~~~~~~~~~
+ *  Encrypts a large file body chunk by chunk, so the peak memory
+ *  only depends on the chunk size, not the file size.
+ *
+ *  Stream format:
+ *      blob  = "DIMF" + version(1) + frame + frame + ...
+ *      frame = len(params) + params + len(ciphertext) + ciphertext
+ *
+ *      params     = canonical_json(extra params of this chunk, e.g.: "IV")
+ *      ciphertext = password.encrypt(index + flag + chunk, params)
+ *
+ *  The 'index' (uint32) and 'flag' (1 for the last chunk) are encrypted
+ *  with the chunk data, and any byte after the last chunk is rejected.
+ *  All lengths are uint32 in big-endian.
+ *
+ *  NOTICE: the frames are NOT authenticated by this format, so reordered
+ *          or truncated streams can only be detected with an authenticated
+ *          cipher (AEAD, e.g.: AES-GCM) which also binds the params;
+ *          with an unauthenticated cipher like AES-CBC, the IV is kept in
+ *          the plain params, and the index & flag in the first block can be
+ *          changed by flipping bits of the IV.
+ */
+
+const FILE_CHUNK_SIZE = 64 * 1024
+
+// FILE_STREAM_FORMAT is the value of "format" in file content & PNF
+// for blobs encrypted by FileEncrypter (whole-blob encrypted if absent)
+const FILE_STREAM_FORMAT = "DIMF/1"
+
+var fileStreamMagic = []byte{'D', 'I', 'M', 'F', 1}
+
+// max size of a single frame field (to stop allocation bombs)
+const maxFrameFieldSize = 16 * 1024 * 1024
+
+var ErrFileStream = errors.New("file stream error")
+
+// FileEncrypter encrypts file body written into it,
+// and writes the encrypted blob into the underlying writer
+//
+// Close() must be called to write the last chunk;
+// use an authenticated cipher for the key if the stream must not be
+// reordered or truncated (see the notice above).
+type FileEncrypter struct {
+	//io.WriteCloser
+
+	writer io.Writer
+	key    EncryptKey
+
+	chunkSize int
+	buffer    []byte
+	index     uint32
+
+	started bool
+	closed  bool
+}
+
+func NewFileEncrypter(writer io.Writer, key EncryptKey, chunkSize int) *FileEncrypter {
+	if chunkSize <= 0 {
+		chunkSize = FILE_CHUNK_SIZE
+	}
+	return &FileEncrypter{
+		writer:    writer,
+		key:       key,
+		chunkSize: chunkSize,
+		buffer:    make([]byte, 0, chunkSize),
+	}
+}
+
+// Override
+func (enc *FileEncrypter) Write(p []byte) (int, error) {
+	if enc.closed {
+		return 0, fmt.Errorf("%w: write after closed", ErrFileStream)
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(enc.buffer) == enc.chunkSize {
+			// only flush when more data coming,
+			// so the last chunk will be written on closing
+			if err := enc.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(enc.buffer[len(enc.buffer):enc.chunkSize], p)
+		enc.buffer = enc.buffer[:len(enc.buffer)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close writes the last chunk
+func (enc *FileEncrypter) Close() error {
+	if enc.closed {
+		return nil
+	}
+	enc.closed = true
+	return enc.flush(true)
+}
+
+func (enc *FileEncrypter) flush(last bool) error {
+	if !enc.started {
+		if _, err := enc.writer.Write(fileStreamMagic); err != nil {
+			return err
+		}
+		enc.started = true
+	}
+	// index + flag + chunk
+	plaintext := make([]byte, 5, 5+len(enc.buffer))
+	binary.BigEndian.PutUint32(plaintext, enc.index)
+	if last {
+		plaintext[4] = 1
+	}
+	plaintext = append(plaintext, enc.buffer...)
+	params := NewMap()
+	ciphertext := enc.key.Encrypt(plaintext, params)
+	if len(ciphertext) == 0 {
+		return fmt.Errorf("%w: failed to encrypt chunk %d", ErrFileStream, enc.index)
+	}
+	var head []byte
+	if len(params) > 0 {
+		head = UTF8Encode(CanonicalJSONEncodeMap(params))
+	}
+	if err := writeFrameField(enc.writer, head); err != nil {
+		return err
+	} else if err = writeFrameField(enc.writer, ciphertext); err != nil {
+		return err
+	}
+	enc.buffer = enc.buffer[:0]
+	enc.index++
+	return nil
+}
+
+func writeFrameField(writer io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := writer.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := writer.Write(data)
+	return err
+}
+
+// FileDecrypter reads the encrypted blob from the underlying reader,
+// and returns the decrypted file body
+type FileDecrypter struct {
+	//io.Reader
+
+	reader io.Reader
+	key    DecryptKey
+
+	pending []byte // decrypted data not read yet
+	index   uint32
+
+	started bool
+	done    bool // last chunk decrypted
+}
+
+func NewFileDecrypter(reader io.Reader, key DecryptKey) *FileDecrypter {
+	return &FileDecrypter{
+		reader: reader,
+		key:    key,
+	}
+}
+
+// Override
+func (dec *FileDecrypter) Read(p []byte) (int, error) {
+	for len(dec.pending) == 0 {
+		if dec.done {
+			return 0, io.EOF
+		} else if err := dec.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dec.pending)
+	dec.pending = dec.pending[n:]
+	return n, nil
+}
+
+func (dec *FileDecrypter) next() error {
+	if !dec.started {
+		magic := make([]byte, len(fileStreamMagic))
+		if _, err := io.ReadFull(dec.reader, magic); err != nil {
+			return fmt.Errorf("%w: %v", ErrFileStream, err)
+		} else if string(magic) != string(fileStreamMagic) {
+			return fmt.Errorf("%w: unknown stream format", ErrFileStream)
+		}
+		dec.started = true
+	}
+	head, err := readFrameField(dec.reader)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := readFrameField(dec.reader)
+	if err != nil {
+		return err
+	}
+	params := NewMap()
+	if len(head) > 0 {
+		params = JSONDecodeMap(UTF8Decode(head))
+		if params == nil {
+			return fmt.Errorf("%w: chunk %d params error", ErrFileStream, dec.index)
+		}
+	}
+	plaintext := dec.key.Decrypt(ciphertext, params)
+	if len(plaintext) < 5 {
+		return fmt.Errorf("%w: failed to decrypt chunk %d", ErrFileStream, dec.index)
+	} else if binary.BigEndian.Uint32(plaintext) != dec.index {
+		return fmt.Errorf("%w: chunk %d out of order", ErrFileStream, dec.index)
+	}
+	dec.done = plaintext[4] == 1
+	dec.pending = plaintext[5:]
+	dec.index++
+	if dec.done {
+		// nothing can follow the last chunk
+		var extra [1]byte
+		n, err := io.ReadFull(dec.reader, extra[:])
+		if n > 0 {
+			dec.pending = nil
+			return fmt.Errorf("%w: trailing data after last chunk", ErrFileStream)
+		} else if err != io.EOF {
+			dec.pending = nil
+			return fmt.Errorf("%w: %v", ErrFileStream, err)
+		}
+	}
+	return nil
+}
+
+func readFrameField(reader io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(reader, size[:]); err != nil {
+		// stream ends before the last chunk
+		return nil, fmt.Errorf("%w: truncated (%v)", ErrFileStream, err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrameFieldSize {
+		return nil, fmt.Errorf("%w: frame too large: %d", ErrFileStream, n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, fmt.Errorf("%w: truncated (%v)", ErrFileStream, err)
+	}
+	return data, nil
+}
+
+//
+//  Stream functions
+//
+
+// EncryptFileStream copies file body from src, and writes encrypted blob to dst
+//
+// Returns: size of the file body
+func EncryptFileStream(dst io.Writer, src io.Reader, key EncryptKey) (int64, error) {
+	enc := NewFileEncrypter(dst, key, FILE_CHUNK_SIZE)
+	buffer := make([]byte, FILE_CHUNK_SIZE)
+	size, err := io.CopyBuffer(enc, src, buffer)
+	if err != nil {
+		return size, err
+	}
+	return size, enc.Close()
+}
+
+// DecryptFileStream reads encrypted blob from src, and writes file body to dst
+//
+// Returns: size of the file body
+func DecryptFileStream(dst io.Writer, src io.Reader, key DecryptKey) (int64, error) {
+	dec := NewFileDecrypter(src, key)
+	buffer := make([]byte, FILE_CHUNK_SIZE)
+	return io.CopyBuffer(dst, dec, buffer)
+}
@@ -0,0 +1,84 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package format_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	. "github.com/dimchat/core-go/format"
+	"github.com/dimchat/core-go/internal/testutil"
+)
+
+var xorKey = testutil.NewXORKey([]byte{0x5a})
+
+func encryptBlob(t *testing.T, body []byte, chunkSize int) []byte {
+	var blob bytes.Buffer
+	enc := NewFileEncrypter(&blob, xorKey, chunkSize)
+	if _, err := enc.Write(body); err != nil {
+		t.Fatal(err)
+	} else if err = enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return blob.Bytes()
+}
+
+func TestFileStreamRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 99, 100, 101, 1000} {
+		body := bytes.Repeat([]byte{'a', 'b', 'c'}, size)[:size]
+		blob := encryptBlob(t, body, 100)
+		var out bytes.Buffer
+		n, err := DecryptFileStream(&out, bytes.NewReader(blob), xorKey)
+		if err != nil {
+			t.Errorf("size %d: %v", size, err)
+		} else if int(n) != size || !bytes.Equal(out.Bytes(), body) {
+			t.Errorf("size %d: body mismatch (%d bytes)", size, n)
+		}
+	}
+}
+
+func TestFileStreamRejected(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 30)
+	blob := encryptBlob(t, body, 100)
+	cases := map[string][]byte{
+		"trailing":  append(append([]byte{}, blob...), 0),
+		"truncated": blob[:len(blob)-1],
+		"magic":     append([]byte("XIMF\x01"), blob[5:]...),
+		"empty":     {},
+	}
+	for name, data := range cases {
+		var out bytes.Buffer
+		if _, err := DecryptFileStream(&out, bytes.NewReader(data), xorKey); !errors.Is(err, ErrFileStream) {
+			t.Errorf("%s: should be rejected, got: %v", name, err)
+		}
+	}
+}
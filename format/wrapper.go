@@ -95,8 +95,8 @@ type TransportableFileWrapper interface {
 func CreateTransportableFileWrapper(content StringKeyMap,
 	data TransportableData, filename string, url URL, password DecryptKey,
 ) TransportableFileWrapper {
-	factory := sharedTransportableFileWrapperFactory
-	return factory.CreateTransportableFileWrapper(content, data, filename, url, password)
+	ctx := GetFormatContext()
+	return ctx.CreateTransportableFileWrapper(content, data, filename, url, password)
 }
 
 /**
@@ -111,24 +111,29 @@ type TransportableFileWrapperFactory interface {
 	) TransportableFileWrapper
 }
 
-var sharedTransportableFileWrapperFactory TransportableFileWrapperFactory = &pnfWrapperFactory{}
-
 func SetTransportableFileWrapperFactory(factory TransportableFileWrapperFactory) {
-	sharedTransportableFileWrapperFactory = factory
+	ctx := GetFormatContext()
+	ctx.SetTransportableFileWrapperFactory(factory)
 }
 
 func GetTransportableFileWrapperFactory() TransportableFileWrapperFactory {
-	return sharedTransportableFileWrapperFactory
+	ctx := GetFormatContext()
+	return ctx.GetTransportableFileWrapperFactory()
 }
 
 type pnfWrapperFactory struct {
 	//TransportableFileWrapperFactory
+
+	// context provides the data coders for the wrappers
+	context *FormatContext
 }
 
-func (pnfWrapperFactory) CreateTransportableFileWrapper(content StringKeyMap,
+func (factory *pnfWrapperFactory) CreateTransportableFileWrapper(content StringKeyMap,
 	data TransportableData, filename string, url URL, key DecryptKey,
 ) TransportableFileWrapper {
-	return NewPortableNetworkFileWrapper(content, data, filename, url, key)
+	wrapper := NewPortableNetworkFileWrapper(content, data, filename, url, key)
+	wrapper.context = factory.context
+	return wrapper
 }
 
 // PortableNetworkFileWrapper is a concrete implementation of TransportableFileWrapper (Mixin)
@@ -171,6 +176,9 @@ type PortableNetworkFileWrapper struct {
 	//
 	// Required to decrypt files downloaded from public CDNs (maps to "key" field in data structure)
 	password DecryptKey
+
+	// context provides the data coders for parsing "data" (nil means the default context)
+	context *FormatContext
 }
 
 func NewPortableNetworkFileWrapper(dict StringKeyMap,
@@ -238,12 +246,21 @@ func (wrapper *PortableNetworkFileWrapper) Data() TransportableData {
 	ted := wrapper.attachment
 	if ted == nil {
 		base64 := wrapper.Get("data")
-		ted = ParseTransportableData(base64)
+		ted = wrapper.parseData(base64)
 		wrapper.attachment = ted
 	}
 	return ted
 }
 
+// data is decoded with the coders of the context
+func (wrapper *PortableNetworkFileWrapper) parseData(data any) TransportableData {
+	ctx := wrapper.context
+	if ctx != nil && ctx != GetFormatContext() {
+		return ctx.ParseTransportableData(data)
+	}
+	return ParseTransportableData(data)
+}
+
 // Override
 func (wrapper *PortableNetworkFileWrapper) SetData(ted TransportableData) {
 	wrapper.Remove("data")
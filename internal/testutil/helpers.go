@@ -0,0 +1,178 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+
+// Package testutil provides the fake helpers shared by the tests,
+// since the real ones (ID, crypto & coders) live in other repositories.
+package testutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/core-go/plugins"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/digest"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/mkm"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+var setupOnce sync.Once
+
+// Setup installs the fake helpers & coders, the XOR key factory,
+// and loads the core extensions (only once)
+func Setup() {
+	setupOnce.Do(func() {
+		SetIDHelper(StubIDHelper{})
+		SetBase64Coder(Base64Coder{})
+		SetJSONCoder(JSONCoder{})
+		SetUTF8Coder(UTF8Coder{})
+		SetTransportableDataHelper(StubDataHelper{})
+		SetSHA256Digester(SHA256Digester{})
+		helper := NewStubKeyHelper()
+		helper.SetSymmetricKeyFactory(XOR, XORKeyFactory{})
+		SetSymmetricKeyHelper(helper)
+		loader := &ExtensionLoader{}
+		if err := loader.Load(); err != nil {
+			panic(err)
+		}
+	})
+}
+
+/**
+ *  ID Helper
+ */
+
+// StubIDHelper parses "name@address/terminal",
+// addresses start with "G" are taken as groups, others as users
+// (except "anywhere" & "everywhere")
+type StubIDHelper struct{}
+
+func (StubIDHelper) SetIDFactory(IDFactory)                 {}
+func (StubIDHelper) GetIDFactory() IDFactory                { return nil }
+func (StubIDHelper) GenerateID(Meta, EntityType, string) ID { return nil }
+
+func (StubIDHelper) CreateID(name string, address Address, terminal string) ID {
+	return NewID(name, address, terminal)
+}
+
+func (StubIDHelper) ParseID(did any) ID {
+	if id, ok := did.(ID); ok {
+		return id
+	}
+	text := ConvertString(did, "")
+	if text == "" {
+		return nil
+	}
+	var name, terminal string
+	if pos := strings.IndexByte(text, '/'); pos >= 0 {
+		text, terminal = text[:pos], text[pos+1:]
+	}
+	if pos := strings.IndexByte(text, '@'); pos >= 0 {
+		name, text = text[:pos], text[pos+1:]
+	}
+	var address Address
+	switch {
+	case text == ANYWHERE.String():
+		address = ANYWHERE
+	case text == EVERYWHERE.String():
+		address = EVERYWHERE
+	case strings.HasPrefix(text, "G"):
+		address = NewBroadcastAddress(text, GROUP)
+	default:
+		address = NewBroadcastAddress(text, USER)
+	}
+	return NewID(name, address, terminal)
+}
+
+// IDFromString parses ID with StubIDHelper (no need to call Setup first)
+func IDFromString(did string) ID {
+	return StubIDHelper{}.ParseID(did)
+}
+
+/**
+ *  TED Helper
+ */
+
+// StubDataHelper parses TED with the coders of the default format context
+type StubDataHelper struct{}
+
+func (StubDataHelper) SetTransportableDataFactory(TransportableDataFactory)  {}
+func (StubDataHelper) GetTransportableDataFactory() TransportableDataFactory { return nil }
+
+func (StubDataHelper) ParseTransportableData(ted any) TransportableData {
+	return GetFormatContext().ParseTransportableData(ted)
+}
+
+/**
+ *  Coders
+ */
+
+type Base64Coder struct{}
+
+func (Base64Coder) Encode(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func (Base64Coder) Decode(text string) []byte {
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+type JSONCoder struct{}
+
+func (JSONCoder) Encode(object any) string {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func (JSONCoder) Decode(text string) any {
+	var object any
+	if err := json.Unmarshal([]byte(text), &object); err != nil {
+		return nil
+	}
+	return object
+}
+
+type UTF8Coder struct{}
+
+func (UTF8Coder) Encode(text string) []byte { return []byte(text) }
+func (UTF8Coder) Decode(data []byte) string { return string(data) }
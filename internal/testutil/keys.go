@@ -0,0 +1,219 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package testutil
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/ext"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+type SHA256Digester struct{}
+
+func (SHA256Digester) Digest(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+/**
+ *  Symmetric Key Helper
+ */
+
+// StubKeyHelper is the minimal helper installed by crypto plugins
+type StubKeyHelper struct {
+	factories map[string]SymmetricKeyFactory
+}
+
+func NewStubKeyHelper() *StubKeyHelper {
+	return &StubKeyHelper{
+		factories: make(map[string]SymmetricKeyFactory),
+	}
+}
+
+func (helper *StubKeyHelper) SetSymmetricKeyFactory(algorithm string, factory SymmetricKeyFactory) {
+	helper.factories[algorithm] = factory
+}
+
+func (helper *StubKeyHelper) GetSymmetricKeyFactory(algorithm string) SymmetricKeyFactory {
+	return helper.factories[algorithm]
+}
+
+func (helper *StubKeyHelper) GenerateSymmetricKey(algorithm string) SymmetricKey {
+	if factory := helper.factories[algorithm]; factory != nil {
+		return factory.GenerateSymmetricKey()
+	}
+	return nil
+}
+
+func (helper *StubKeyHelper) ParseSymmetricKey(key any) SymmetricKey {
+	info := FetchMap(key)
+	if factory := helper.factories[ConvertString(info["algorithm"], "")]; factory != nil {
+		return factory.ParseSymmetricKey(info)
+	}
+	return nil
+}
+
+/**
+ *  XOR Key
+ */
+
+const XOR = "XOR"
+
+// XORKey is a symmetric key for tests only, never use it for real data
+type XORKey struct {
+	*Dictionary
+
+	secret []byte
+}
+
+func NewXORKey(secret []byte) *XORKey {
+	return &XORKey{
+		Dictionary: NewDictionary(StringKeyMap{
+			"algorithm": XOR,
+			"data":      Base64Coder{}.Encode(secret),
+		}),
+		secret: secret,
+	}
+}
+
+func (key *XORKey) Algorithm() string { return XOR }
+
+func (key *XORKey) Data() TransportableData {
+	return NewBase64DataWithBytes(key.secret)
+}
+
+func (key *XORKey) Encrypt(plaintext []byte, _ StringKeyMap) []byte {
+	return xor(plaintext, key.secret)
+}
+
+func (key *XORKey) Decrypt(ciphertext []byte, _ StringKeyMap) []byte {
+	return xor(ciphertext, key.secret)
+}
+
+func (key *XORKey) MatchEncryptKey(pKey EncryptKey) bool {
+	return MatchSymmetricKeys(pKey, key)
+}
+
+type XORKeyFactory struct{}
+
+func (XORKeyFactory) GenerateSymmetricKey() SymmetricKey {
+	return NewXORKey([]byte("0123456789abcdef"))
+}
+
+func (XORKeyFactory) ParseSymmetricKey(key StringKeyMap) SymmetricKey {
+	secret := Base64Coder{}.Decode(ConvertString(key["data"], ""))
+	if len(secret) == 0 {
+		return nil
+	}
+	return NewXORKey(secret)
+}
+
+func xor(data []byte, secret []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ secret[i%len(secret)]
+	}
+	return out
+}
+
+/**
+ *  Asymmetric Keys
+ */
+
+// NewKeyPair creates a fake key pair for tests only,
+// both keys share the same secret, so never use it for real data
+func NewKeyPair(seed string) (*StubPrivateKey, *StubPublicKey) {
+	hash := sha256.Sum256([]byte(seed))
+	secret := hash[:]
+	info := StringKeyMap{
+		"algorithm": STUB,
+		"data":      Base64Coder{}.Encode(secret),
+	}
+	return &StubPrivateKey{NewDictionary(CopyMap(info)), secret},
+		&StubPublicKey{NewDictionary(CopyMap(info)), secret}
+}
+
+const STUB = "STUB"
+
+type StubPrivateKey struct {
+	*Dictionary
+	//DecryptKey, SignKey
+	secret []byte
+}
+
+func (key *StubPrivateKey) Algorithm() string       { return STUB }
+func (key *StubPrivateKey) Data() TransportableData { return NewBase64DataWithBytes(key.secret) }
+
+func (key *StubPrivateKey) Decrypt(ciphertext []byte, _ StringKeyMap) []byte {
+	if len(ciphertext) < 4 || !bytes.Equal(ciphertext[:4], key.secret[:4]) {
+		return nil
+	}
+	return xor(ciphertext[4:], key.secret)
+}
+
+func (key *StubPrivateKey) MatchEncryptKey(pKey EncryptKey) bool {
+	return MatchSymmetricKeys(pKey, key)
+}
+
+func (key *StubPrivateKey) Sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, key.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+type StubPublicKey struct {
+	*Dictionary
+	//EncryptKey, VerifyKey
+	secret []byte
+}
+
+func (key *StubPublicKey) Algorithm() string       { return STUB }
+func (key *StubPublicKey) Data() TransportableData { return NewBase64DataWithBytes(key.secret) }
+
+func (key *StubPublicKey) Encrypt(plaintext []byte, _ StringKeyMap) []byte {
+	return append(append([]byte{}, key.secret[:4]...), xor(plaintext, key.secret)...)
+}
+
+func (key *StubPublicKey) Verify(data []byte, signature []byte) bool {
+	mac := hmac.New(sha256.New, key.secret)
+	mac.Write(data)
+	return hmac.Equal(mac.Sum(nil), signature)
+}
+
+func (key *StubPublicKey) MatchSignKey(sKey SignKey) bool {
+	return MatchAsymmetricKeys(sKey, key)
+}
@@ -48,7 +48,7 @@ type BaseDocument struct {
 
 	// data stores the JSON-serialized string of the document properties
 	//
-	// Generated via JSONEncode(properties), cached for performance optimization
+	// Generated via CanonicalJSONEncode(properties), cached for performance optimization
 	data string
 
 	// signature stores the digital signature of the serialized properties
@@ -183,7 +183,7 @@ func (doc *BaseDocument) Sign(privateKey SignKey) []byte {
 	if info == nil {
 		return nil
 	}
-	data := JSONEncodeMap(info)
+	data := CanonicalJSONEncodeMap(info) // sorted keys
 	signature := privateKey.Sign(UTF8Encode(data))
 	ted := NewBase64DataWithBytes(signature)
 	// 3. update 'data' & 'signature' fields
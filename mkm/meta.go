@@ -225,3 +225,26 @@ func (meta *BaseMeta) checkValid() bool {
 func (meta *BaseMeta) GenerateAddress(network EntityType) Address {
 	panic("BaseMeta::GenerateAddress(network) > implement me!")
 }
+
+/**
+ *  Meta Verification
+ */
+
+// MetaMatchID checks whether the meta can generate the ID
+func MetaMatchID(meta Meta, did ID) bool {
+	if meta == nil || did == nil || !meta.IsValid() {
+		return false
+	} else if meta.Seed() != did.Name() {
+		return false
+	}
+	address := meta.GenerateAddress(did.Type())
+	return address != nil && address.Equal(did.Address())
+}
+
+// MetaMatchSignKey checks whether the private key pairs with the meta key
+func MetaMatchSignKey(meta Meta, sKey SignKey) bool {
+	if meta == nil || sKey == nil {
+		return false
+	}
+	return MatchAsymmetricKeys(sKey, meta.PublicKey())
+}
@@ -0,0 +1,207 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"sync"
+
+	. "github.com/dimchat/core-go/mkm"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/*
+ *  Meta & Visa
+ *  ~~~~~~~~~~~
+ *  the sender's meta & visa can be attached to the message for the first
+ *  contact, so the receiver doesn't need to query them from the station
+ *  before verifying the signature and replying an encrypted message.
+ */
+
+func (msg *NetworkMessage) Meta() Meta {
+	return ParseMeta(msg.Get("meta"))
+}
+
+func (msg *NetworkMessage) SetMeta(meta Meta) {
+	msg.SetMapper("meta", meta)
+}
+
+func (msg *NetworkMessage) Visa() Visa {
+	doc := ParseDocument(msg.Get("visa"))
+	if visa, ok := doc.(Visa); ok {
+		return visa
+	}
+	return nil
+}
+
+func (msg *NetworkMessage) SetVisa(visa Visa) {
+	msg.SetMapper("visa", visa)
+}
+
+// AttachPolicy decides when to attach the sender's meta & visa
+type AttachPolicy interface {
+
+	// ShouldAttach checks whether the receiver needs the sender's visa,
+	// nothing will be changed
+	//
+	// Parameters:
+	//   - receiver : message receiver
+	//   - visa     : current visa of the sender
+	//
+	// Returns: true to attach meta & visa to this message
+	ShouldAttach(receiver ID, visa Visa) bool
+
+	// MarkAttached remembers the visa has been delivered to the receiver,
+	// call it after the message with meta & visa is sent successfully
+	MarkAttached(receiver ID, visa Visa)
+}
+
+// MetaVisaStorage keeps the meta & visa extracted from messages
+type MetaVisaStorage interface {
+
+	// GetMeta returns the stored meta of the user
+	// (for verifying a visa which comes without meta)
+	GetMeta(user ID) Meta
+
+	SaveMeta(meta Meta, user ID) bool
+
+	SaveVisa(visa Visa, user ID) bool
+}
+
+// AttachMetaVisa embeds the sender's meta & visa into message
+// if the policy says the receiver needs them
+//
+// Returns: true on attached, then call policy.MarkAttached()
+// after the message is sent
+func AttachMetaVisa(rMsg *NetworkMessage, meta Meta, visa Visa, policy AttachPolicy) bool {
+	if meta == nil || visa == nil {
+		return false
+	} else if policy != nil && !policy.ShouldAttach(rMsg.Receiver(), visa) {
+		return false
+	}
+	rMsg.SetMeta(meta)
+	rMsg.SetVisa(visa)
+	return true
+}
+
+// ExtractMetaVisa verifies the meta & visa attached in message,
+// and hands them to the storage
+//
+// Returns: UnpackError with reason ErrInvalidMeta / ErrInvalidVisa
+func ExtractMetaVisa(rMsg *NetworkMessage, storage MetaVisaStorage) error {
+	sender := rMsg.Sender()
+	// 1. check meta
+	meta := rMsg.Meta()
+	if meta != nil {
+		if !MetaMatchID(meta, sender) {
+			return NewUnpackError(ErrInvalidMeta, "meta not match sender", rMsg)
+		}
+		storage.SaveMeta(meta, sender)
+	} else if rMsg.Contains("meta") {
+		return NewUnpackError(ErrInvalidMeta, "failed to parse meta", rMsg)
+	} else {
+		meta = storage.GetMeta(sender)
+	}
+	// 2. check visa
+	visa := rMsg.Visa()
+	if visa == nil {
+		if rMsg.Contains("visa") {
+			return NewUnpackError(ErrInvalidVisa, "failed to parse visa", rMsg)
+		}
+		return nil
+	} else if meta == nil {
+		return NewUnpackError(ErrInvalidVisa, "meta not found for verifying visa", rMsg)
+	}
+	did := ParseID(visa.Get("did"))
+	if did == nil || !did.Equal(sender) {
+		return NewUnpackError(ErrInvalidVisa, "visa not match sender", rMsg)
+	} else if !visa.Verify(meta.PublicKey()) {
+		return NewUnpackError(ErrInvalidVisa, "visa signature not match", rMsg)
+	}
+	storage.SaveVisa(visa, sender)
+	return nil
+}
+
+/**
+ *  First Contact Policy
+ *  ~~~~~~~~~~~~~~~~~~~~
+ *  attaches meta & visa to the first message for each contact,
+ *  and again after the visa changed (signed at a later time).
+ */
+type FirstContactAttachPolicy struct {
+	//AttachPolicy
+
+	mutex sync.Mutex
+	sent  map[string]float64 // contact => time of the visa attached
+}
+
+func NewFirstContactAttachPolicy() *FirstContactAttachPolicy {
+	return &FirstContactAttachPolicy{
+		sent: map[string]float64{},
+	}
+}
+
+// Override
+func (policy *FirstContactAttachPolicy) ShouldAttach(receiver ID, visa Visa) bool {
+	visaTime := getVisaTime(visa)
+	policy.mutex.Lock()
+	defer policy.mutex.Unlock()
+	last, exists := policy.sent[receiver.String()]
+	// attach if not sent yet, or the visa changed
+	return !exists || last < visaTime
+}
+
+// Override
+func (policy *FirstContactAttachPolicy) MarkAttached(receiver ID, visa Visa) {
+	visaTime := getVisaTime(visa)
+	key := receiver.String()
+	policy.mutex.Lock()
+	defer policy.mutex.Unlock()
+	if last, exists := policy.sent[key]; !exists || last < visaTime {
+		policy.sent[key] = visaTime
+	}
+}
+
+func getVisaTime(visa Visa) float64 {
+	if when := visa.Time(); !TimeIsNil(when) {
+		return TimeToFloat64(when)
+	}
+	return 0
+}
+
+// Reset forgets the contact, so meta & visa will be attached again
+// (e.g.: when the contact queries them)
+func (policy *FirstContactAttachPolicy) Reset(receiver ID) {
+	policy.mutex.Lock()
+	defer policy.mutex.Unlock()
+	delete(policy.sent, receiver.String())
+}
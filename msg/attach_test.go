@@ -0,0 +1,81 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"testing"
+
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// only Time() is used by the policy
+type stubVisa struct {
+	Visa
+	when float64
+}
+
+func (visa stubVisa) Time() Time { return TimeFromFloat64(visa.when) }
+
+func TestFirstContactAttachPolicy(t *testing.T) {
+	policy := NewFirstContactAttachPolicy()
+	visa := stubVisa{when: 1700000000}
+
+	// query only, nothing remembered (e.g.: the message failed to send)
+	if !policy.ShouldAttach(hulk, visa) || !policy.ShouldAttach(hulk, visa) {
+		t.Fatalf("should attach for the first contact")
+	}
+	policy.MarkAttached(hulk, visa)
+	if policy.ShouldAttach(hulk, visa) {
+		t.Errorf("should not attach again after sent")
+	} else if !policy.ShouldAttach(frank, visa) {
+		t.Errorf("should attach for other contacts")
+	}
+
+	// visa changed
+	newer := stubVisa{when: 1700000100}
+	if !policy.ShouldAttach(hulk, newer) {
+		t.Errorf("should attach the new visa")
+	}
+	policy.MarkAttached(hulk, newer)
+	// the old one sent later will not roll back
+	policy.MarkAttached(hulk, visa)
+	if policy.ShouldAttach(hulk, newer) {
+		t.Errorf("should not attach the new visa again")
+	}
+
+	// contact queries again
+	policy.Reset(hulk)
+	if !policy.ShouldAttach(hulk, newer) {
+		t.Errorf("should attach after reset")
+	}
+}
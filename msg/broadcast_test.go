@@ -0,0 +1,100 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/dimchat/core-go/dkd"
+	"github.com/dimchat/core-go/internal/testutil"
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/mkm"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+func newBroadcastMessage(text string) InstantMessage {
+	sender := ParseID("moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk")
+	env := NewEnvelope(sender, EVERYONE, TimeNow())
+	return NewInstantMessage(env, NewTextContent(text))
+}
+
+func TestBroadcastMessageReadable(t *testing.T) {
+	text := "Hello, 世界! (broadcast)"
+	iMsg := newBroadcastMessage(text)
+
+	packer := NewInstantMessagePacker(nil)
+	// compression must be skipped for broadcast message
+	packer.EnableCompression(GetCompressor(DEFLATE), 0)
+	sMsg, missed := packer.Encrypt(iMsg, nil, nil)
+	if sMsg == nil || len(missed) > 0 {
+		t.Fatalf("failed to encrypt broadcast message")
+	}
+	info := sMsg.Map()
+	if _, exists := info["keys"]; exists {
+		t.Errorf("broadcast message should not carry keys: %v", info["keys"])
+	} else if _, exists = info["key"]; exists {
+		t.Errorf("broadcast message should not carry key: %v", info["key"])
+	} else if _, exists = info["compression"]; exists {
+		t.Errorf("broadcast message should not be compressed")
+	}
+	// 'data' is the content in JsON, not base64
+	data, ok := info["data"].(string)
+	if !ok || !strings.HasPrefix(data, "{") {
+		t.Fatalf("broadcast data should be JsON text: %v", info["data"])
+	}
+	content := JSONDecodeMap(data)
+	if content == nil || content["text"] != text {
+		t.Errorf("broadcast content error: %s", data)
+	}
+	// the text is still readable on the wire
+	wire := string(EncodeMessage(JSON_CODEC, sMsg))
+	if !strings.Contains(wire, text) {
+		t.Errorf("broadcast text not readable: %s", wire)
+	}
+
+	// decrypt back without any key
+	iMsg2, err := NewSecureMessagePacker(nil).Decrypt(sMsg, EVERYONE)
+	if err != nil {
+		t.Fatalf("failed to decrypt broadcast message: %v", err)
+	}
+	if body, ok := iMsg2.Content().(TextContent); !ok || body.Text() != text {
+		t.Errorf("broadcast content error: %v", iMsg2.Content())
+	}
+}
@@ -0,0 +1,279 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"sort"
+	"sync"
+
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// MessageCodec serializes message trees (Envelope, SecureMessage,
+// ReliableMessage) for transport
+//
+// A station and its clients can negotiate the codec by name,
+// "json" is always available as the fallback.
+type MessageCodec interface {
+
+	// Name returns the codec name, e.g.: "json", "msgpack"
+	Name() string
+
+	// Encode serializes a message map to binary data
+	Encode(msg StringKeyMap) []byte
+
+	// Decode parses binary data back to a message map
+	//
+	// Returns: nil on error
+	Decode(data []byte) StringKeyMap
+}
+
+const (
+	JSON_CODEC    = "json"
+	MSGPACK_CODEC = "msgpack"
+)
+
+//
+//  Codec registry
+//
+
+var codecLock sync.RWMutex
+var messageCodecs = map[string]MessageCodec{}
+
+func SetMessageCodec(codec MessageCodec) {
+	codecLock.Lock()
+	defer codecLock.Unlock()
+	messageCodecs[codec.Name()] = codec
+}
+
+func GetMessageCodec(name string) MessageCodec {
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+	return messageCodecs[name]
+}
+
+// MessageCodecNames returns names of all registered codecs (sorted)
+func MessageCodecNames() []string {
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+	names := make([]string, 0, len(messageCodecs))
+	for name := range messageCodecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NegotiateMessageCodec picks the first codec offered by the peer
+// which is supported here
+//
+// Parameters:
+//   - offered: codec names in the peer's order of preference
+//
+// Returns: the chosen codec, JsON codec if none matched
+func NegotiateMessageCodec(offered []string) MessageCodec {
+	for _, name := range offered {
+		codec := GetMessageCodec(name)
+		if codec != nil {
+			return codec
+		}
+	}
+	return GetMessageCodec(JSON_CODEC)
+}
+
+//
+//  Factory methods
+//
+
+// EncodeMessage serializes the message with the codec
+//
+// Returns: nil if the codec is unknown
+func EncodeMessage(codec string, msg Mapper) []byte {
+	coder := GetMessageCodec(codec)
+	if coder == nil || ValueIsNil(msg) {
+		//panic("message codec not found: " + codec)
+		return nil
+	}
+	return coder.Encode(msg.Map())
+}
+
+// decodeMessage returns nil if the codec is unknown or the data is broken
+func decodeMessage(codec string, data []byte) StringKeyMap {
+	coder := GetMessageCodec(codec)
+	if coder == nil || len(data) == 0 {
+		//panic("message codec not found: " + codec)
+		return nil
+	}
+	return coder.Decode(data)
+}
+
+// DecodeEnvelope parses the data with the codec
+//
+// Returns: nil if the codec is unknown or the data is broken
+func DecodeEnvelope(codec string, data []byte) Envelope {
+	info := decodeMessage(codec, data)
+	if info == nil {
+		return nil
+	}
+	return ParseEnvelope(info)
+}
+
+func DecodeSecureMessage(codec string, data []byte) SecureMessage {
+	info := decodeMessage(codec, data)
+	if info == nil {
+		return nil
+	}
+	return ParseSecureMessage(info)
+}
+
+func DecodeReliableMessage(codec string, data []byte) ReliableMessage {
+	info := decodeMessage(codec, data)
+	if info == nil {
+		return nil
+	}
+	return ParseReliableMessage(info)
+}
+
+func init() {
+	SetMessageCodec(&jsonMessageCodec{})
+	SetMessageCodec(&binaryMessageCodec{})
+}
+
+/**
+ *  JsON Codec
+ *  ~~~~~~~~~~
+ *  the original text form, binary fields are base64 strings
+ */
+type jsonMessageCodec struct {
+	//MessageCodec
+}
+
+// Override
+func (*jsonMessageCodec) Name() string {
+	return JSON_CODEC
+}
+
+// Override
+func (*jsonMessageCodec) Encode(msg StringKeyMap) []byte {
+	js := JSONEncodeMap(msg)
+	return UTF8Encode(js)
+}
+
+// Override
+func (*jsonMessageCodec) Decode(data []byte) StringKeyMap {
+	js := UTF8Decode(data)
+	if js == "" {
+		return nil
+	}
+	return JSONDecodeMap(js)
+}
+
+/**
+ *  MessagePack Codec
+ *  ~~~~~~~~~~~~~~~~~
+ *  binary fields ('data', 'signature', 'key', 'keys') are sent as raw bytes,
+ *  and restored to the same base64 strings after decoded,
+ *  so the round trip with JsON form is lossless.
+ */
+type binaryMessageCodec struct {
+	//MessageCodec
+	coder MessagePackCoder
+}
+
+// Override
+func (*binaryMessageCodec) Name() string {
+	return MSGPACK_CODEC
+}
+
+// Override
+func (codec *binaryMessageCodec) Encode(msg StringKeyMap) []byte {
+	info := CopyMap(msg)
+	for _, name := range binaryFields {
+		if value, exists := info[name]; exists {
+			info[name] = packBinary(value)
+		}
+	}
+	if keys := FetchMap(info["keys"]); keys != nil {
+		packed := make(StringKeyMap, len(keys))
+		for receiver, value := range keys {
+			packed[receiver] = packBinary(value)
+		}
+		info["keys"] = packed
+	}
+	return codec.coder.Encode(info)
+}
+
+// Override
+func (codec *binaryMessageCodec) Decode(data []byte) StringKeyMap {
+	info := FetchMap(codec.coder.Decode(data))
+	if info == nil {
+		return nil
+	}
+	for _, name := range binaryFields {
+		if value, exists := info[name]; exists {
+			info[name] = unpackBinary(value)
+		}
+	}
+	if keys := FetchMap(info["keys"]); keys != nil {
+		for receiver, value := range keys {
+			keys[receiver] = unpackBinary(value)
+		}
+	}
+	return info
+}
+
+var binaryFields = []string{"data", "signature", "key"}
+
+// base64 string -> raw bytes, only when it can be restored exactly
+func packBinary(value any) any {
+	b64, ok := value.(string)
+	if !ok {
+		return value
+	}
+	bin := Base64Decode(b64)
+	if bin == nil || Base64Encode(bin) != b64 {
+		// not a base64 string (e.g.: plaintext of broadcast message)
+		return value
+	}
+	return bin
+}
+
+// raw bytes -> base64 string
+func unpackBinary(value any) any {
+	bin, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	return Base64Encode(bin)
+}
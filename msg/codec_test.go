@@ -0,0 +1,151 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+var codecCases = []struct {
+	name string
+	msg  Mapper
+}{
+	{"envelope", NewMessageEnvelope(StringKeyMap{
+		"sender":   "moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk",
+		"receiver": "hulk@4YeVEN3aUnvC1DNUufCq1bs9zoBSJTzVEj",
+		"time":     1700000000,
+		"type":     1,
+	}, nil, nil, nil)},
+	{"encrypted message", NewEncryptedMessage(StringKeyMap{
+		"sender":   "moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk",
+		"receiver": "group@4YeVEN3aUnvC1DNUufCq1bs9zoBSJTzVEj",
+		"time":     1700000000.123,
+		"data":     "AQIDBAUGBwg=",
+		"keys": StringKeyMap{
+			"moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk": "CQoLDA==",
+			"hulk@4YeVEN3aUnvC1DNUufCq1bs9zoBSJTzVEj": "DQ4PEA==",
+			"digest": "abcd",
+		},
+	}, nil)},
+	{"network message", NewNetworkMessage(StringKeyMap{
+		"sender":    "moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk",
+		"receiver":  "hulk@4YeVEN3aUnvC1DNUufCq1bs9zoBSJTzVEj",
+		"time":      1700000000.5,
+		"data":      "AQIDBAUGBwg=",
+		"key":       "CQoLDA==",
+		"signature": "ERITFBUWFxg=",
+		"traces": []any{
+			"station@2PpB6iscuBjA15oTjAsiswoX9qis5V3c1Dq",
+			StringKeyMap{"ID": "station@2PpB6iscuBjA15oTjAsiswoX9qis5V3c1Dq", "time": 1700000001},
+		},
+		"title": "你好, мир 🌏",
+	}, nil, nil)},
+	{"broadcast message", NewNetworkMessage(StringKeyMap{
+		"sender":    "moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk",
+		"receiver":  "everyone@everywhere",
+		"time":      1700000000,
+		"data":      "{\"type\":1,\"sn\":42,\"text\":\"Hello, 世界!\"}",
+		"signature": "ERITFBUWFxg=",
+	}, nil, nil)},
+}
+
+func TestMessageCodecRoundTrip(t *testing.T) {
+	for _, name := range []string{JSON_CODEC, MSGPACK_CODEC} {
+		for _, tc := range codecCases {
+			original := tc.msg.Map()
+			data := EncodeMessage(name, tc.msg)
+			if len(data) == 0 {
+				t.Errorf("%s/%s: failed to encode", name, tc.name)
+				continue
+			}
+			info := GetMessageCodec(name).Decode(data)
+			if info == nil {
+				t.Errorf("%s/%s: failed to decode", name, tc.name)
+				continue
+			}
+			// numbers may come back as int or float, compare in JsON form
+			expected := JSONEncodeMap(original)
+			actual := JSONEncodeMap(info)
+			if actual != expected {
+				t.Errorf("%s/%s: round trip mismatch\n got: %s\nwant: %s", name, tc.name, actual, expected)
+			}
+		}
+	}
+}
+
+func TestMessagePackBinaryFields(t *testing.T) {
+	msg := codecCases[2].msg
+	data := EncodeMessage(MSGPACK_CODEC, msg)
+	// base64 fields are packed as raw bytes
+	if bytes.Contains(data, []byte("ERITFBUWFxg=")) {
+		t.Errorf("signature should be packed as raw bytes")
+	} else if !bytes.Contains(data, []byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18}) {
+		t.Errorf("raw signature not found")
+	}
+	// smaller than JsON
+	js := EncodeMessage(JSON_CODEC, msg)
+	if len(data) >= len(js) {
+		t.Errorf("msgpack size %d should be less than json %d", len(data), len(js))
+	}
+	// broken data
+	if info := GetMessageCodec(MSGPACK_CODEC).Decode(append(data, 0xc0)); info != nil {
+		t.Errorf("trailing bytes should be rejected: %v", info)
+	}
+	if info := GetMessageCodec(MSGPACK_CODEC).Decode(data[:len(data)/2]); info != nil {
+		t.Errorf("truncated data should be rejected: %v", info)
+	}
+}
+
+func TestUnknownMessageCodec(t *testing.T) {
+	msg := codecCases[0].msg
+	if data := EncodeMessage("xml", msg); data != nil {
+		t.Errorf("unknown codec should encode nothing: %v", data)
+	}
+	data := EncodeMessage(JSON_CODEC, msg)
+	if env := DecodeEnvelope("xml", data); env != nil {
+		t.Errorf("unknown codec should decode nothing: %v", env)
+	} else if sMsg := DecodeSecureMessage("xml", data); sMsg != nil {
+		t.Errorf("unknown codec should decode nothing: %v", sMsg)
+	} else if rMsg := DecodeReliableMessage("xml", data); rMsg != nil {
+		t.Errorf("unknown codec should decode nothing: %v", rMsg)
+	}
+	if codec := NegotiateMessageCodec([]string{"xml", MSGPACK_CODEC}); codec == nil || codec.Name() != MSGPACK_CODEC {
+		t.Errorf("codec negotiation error: %v", codec)
+	}
+	if codec := NegotiateMessageCodec([]string{"xml"}); codec == nil || codec.Name() != JSON_CODEC {
+		t.Errorf("codec should fall back to json: %v", codec)
+	}
+}
@@ -0,0 +1,164 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Compressor compresses the serialized content before it is encrypted
+//
+// The algorithm name will be recorded in the message envelope as
+// 'compression', so the receiver can decompress it after decrypted.
+//
+// NOTICE: 'compression' is NOT covered by the signature (which only signs
+// 'data'), so it could be changed or removed on the way; that only makes the
+// message fail to unpack (ErrDecompress / ErrContentParse), the content
+// itself is still protected by the signature & the message key.
+type Compressor interface {
+
+	// Algorithm returns the compression name, e.g.: "deflate"
+	Algorithm() string
+
+	// Compress returns the compressed data (nil on failed)
+	Compress(data []byte) []byte
+
+	// Decompress restores the original data
+	//
+	// Parameters:
+	//   - data  : compressed data
+	//   - limit : max size of the original data, to stop decompression bombs
+	//
+	// Returns: original data, or error if corrupted or too large
+	Decompress(data []byte, limit int) ([]byte, error)
+}
+
+const (
+	DEFLATE = "deflate"
+)
+
+// max size of decompressed content (16 MB)
+const MAX_DECOMPRESSED_SIZE = 16 * 1024 * 1024
+
+//
+//  Compressor registry
+//
+
+var compressorLock sync.RWMutex
+var compressors = map[string]Compressor{}
+
+func SetCompressor(compressor Compressor) {
+	compressorLock.Lock()
+	defer compressorLock.Unlock()
+	compressors[compressor.Algorithm()] = compressor
+}
+
+func GetCompressor(algorithm string) Compressor {
+	compressorLock.RLock()
+	defer compressorLock.RUnlock()
+	return compressors[algorithm]
+}
+
+// CompressorNames returns names of all registered algorithms (sorted)
+func CompressorNames() []string {
+	compressorLock.RLock()
+	defer compressorLock.RUnlock()
+	names := make([]string, 0, len(compressors))
+	for name := range compressors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NegotiateCompressor picks the first algorithm offered by the peer
+// which is supported here
+//
+// Returns: nil if none matched (no compression)
+func NegotiateCompressor(offered []string) Compressor {
+	for _, name := range offered {
+		compressor := GetCompressor(name)
+		if compressor != nil {
+			return compressor
+		}
+	}
+	return nil
+}
+
+func init() {
+	SetCompressor(&deflateCompressor{})
+}
+
+/**
+ *  Deflate (RFC 1951)
+ */
+type deflateCompressor struct {
+	//Compressor
+}
+
+// Override
+func (*deflateCompressor) Algorithm() string {
+	return DEFLATE
+}
+
+// Override
+func (*deflateCompressor) Compress(data []byte) []byte {
+	var buffer bytes.Buffer
+	writer, err := flate.NewWriter(&buffer, flate.BestCompression)
+	if err != nil {
+		return nil
+	}
+	if _, err = writer.Write(data); err != nil {
+		return nil
+	} else if err = writer.Close(); err != nil {
+		return nil
+	}
+	return buffer.Bytes()
+}
+
+// Override
+func (*deflateCompressor) Decompress(data []byte, limit int) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(data))
+	defer reader.Close()
+	// read one more byte to check whether it exceeds the limit
+	body, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	} else if len(body) > limit {
+		return nil, fmt.Errorf("decompressed size exceeds %d bytes", limit)
+	}
+	return body, nil
+}
@@ -0,0 +1,137 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dimchat/core-go/internal/testutil"
+
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+func TestDeflateRoundTrip(t *testing.T) {
+	compressor := GetCompressor(DEFLATE)
+	if compressor == nil {
+		t.Fatalf("deflate not registered: %v", CompressorNames())
+	}
+	for _, size := range []int{0, 1, 100, 100000} {
+		data := bytes.Repeat([]byte("Hello, world! "), size/14+1)[:size]
+		compressed := compressor.Compress(data)
+		if compressed == nil {
+			t.Fatalf("failed to compress %d bytes", size)
+		}
+		body, err := compressor.Decompress(compressed, size)
+		if err != nil {
+			t.Errorf("failed to decompress %d bytes: %v", size, err)
+		} else if !bytes.Equal(body, data) {
+			t.Errorf("data not match for %d bytes", size)
+		}
+	}
+	// corrupted
+	if _, err := compressor.Decompress([]byte("not deflate"), 1024); err == nil {
+		t.Errorf("corrupted data should fail")
+	}
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	compressor := GetCompressor(DEFLATE)
+	bomb := compressor.Compress(make([]byte, 1024*1024))
+	if len(bomb) > 4096 {
+		t.Fatalf("bomb too large: %d", len(bomb))
+	}
+	if _, err := compressor.Decompress(bomb, 1024*1024-1); err == nil {
+		t.Errorf("decompressed size over limit should fail")
+	} else if body, err := compressor.Decompress(bomb, 1024*1024); err != nil || len(body) != 1024*1024 {
+		t.Errorf("decompressed size at limit should pass: %d, %v", len(body), err)
+	}
+}
+
+func TestNegotiateCompressor(t *testing.T) {
+	if c := NegotiateCompressor([]string{"zstd", DEFLATE}); c == nil || c.Algorithm() != DEFLATE {
+		t.Errorf("should pick deflate: %v", c)
+	} else if c = NegotiateCompressor([]string{"zstd"}); c != nil {
+		t.Errorf("should not compress: %v", c)
+	}
+}
+
+func TestPackerCompression(t *testing.T) {
+	store := newKeyStore(moki, hulk)
+	text := string(bytes.Repeat([]byte("Hello, world! "), 1000))
+	iMsg := NewInstantMessage(NewEnvelope(moki, hulk, TimeNow()), NewTextContent(text))
+
+	packer := NewInstantMessagePacker(store)
+	packer.EnableCompression(GetCompressor(DEFLATE), 1024)
+	password := testutil.NewXORKey([]byte("0123456789abcdef"))
+	sMsg, _ := packer.Encrypt(iMsg, password, nil)
+	if sMsg == nil {
+		t.Fatalf("failed to encrypt message")
+	} else if sMsg.GetString("compression", "") != DEFLATE {
+		t.Fatalf("content should be compressed: %v", sMsg.Get("compression"))
+	}
+
+	// round trip
+	decrypter := NewSecureMessagePacker(store)
+	msg, err := decrypter.Decrypt(sMsg, hulk)
+	if err != nil {
+		t.Fatal(err)
+	} else if body, ok := msg.Content().(TextContent); !ok || body.Text() != text {
+		t.Errorf("content error")
+	} else if msg.Contains("compression") {
+		t.Errorf("'compression' should be removed")
+	}
+
+	// over limit
+	decrypter.SetMaxContentSize(1024)
+	if _, err = decrypter.Decrypt(sMsg, hulk); !errors.Is(err, ErrDecompress) {
+		t.Errorf("content over limit should fail with ErrDecompress: %v", err)
+	}
+
+	// unknown algorithm
+	info := CopyMap(sMsg.Map())
+	info["compression"] = "zstd"
+	_, err = NewSecureMessagePacker(store).Decrypt(NewSecureMessageWithMap(info), hulk)
+	if !errors.Is(err, ErrDecompress) {
+		t.Errorf("unknown compression should fail with ErrDecompress: %v", err)
+	}
+
+	// 'compression' removed on the way
+	delete(info, "compression")
+	_, err = NewSecureMessagePacker(store).Decrypt(NewSecureMessageWithMap(info), hulk)
+	if !errors.Is(err, ErrContentParse) {
+		t.Errorf("compressed content without 'compression' should fail: %v", err)
+	}
+}
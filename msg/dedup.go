@@ -0,0 +1,283 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Message Deduplicator
+ *  ~~~~~~~~~~~~~~~~~~~~
+ *  Rejects replayed messages, keyed on:
+ *      1. sender + digest(data + signature) (reliable message, after verified);
+ *      2. sender + content SN (instant message, after decrypting).
+ *
+ *  Messages with time older than the window (or too far in the future)
+ *  are rejected directly, so the records only need to be kept for
+ *  (window + skew); records are never dropped before expired, when the
+ *  capacity is reached, new messages will be rejected with ErrDedupFull.
+ *
+ *  Checking and recording are separated: Check*() is read-only, so it can
+ *  be used to drop replays before the expensive verifying; Record*() MUST
+ *  be called only after the signature is verified, otherwise a forged
+ *  message could occupy the key of a genuine one.
+ */
+type MessageDeduplicator struct {
+	mutex sync.Mutex
+
+	// accept messages with time in [now - window, now + maxSkew]
+	window  time.Duration
+	maxSkew time.Duration
+
+	// max count of records
+	capacity int
+
+	records map[string]*list.Element // key => element of dedupRecord
+	queue   *list.List               // records in order of expiration
+}
+
+type dedupRecord struct {
+	key    string
+	expire time.Time
+}
+
+// NewMessageDeduplicator creates a deduplicator
+//
+// Parameters:
+//   - window   : max age of messages to be accepted (must be positive)
+//   - maxSkew  : max clock skew for messages from the future
+//   - capacity : max count of records (must be positive)
+func NewMessageDeduplicator(window, maxSkew time.Duration, capacity int) (*MessageDeduplicator, error) {
+	if window <= 0 || maxSkew < 0 {
+		return nil, fmt.Errorf("dedup window error: %v, %v", window, maxSkew)
+	} else if capacity <= 0 {
+		return nil, fmt.Errorf("dedup capacity error: %d", capacity)
+	}
+	return &MessageDeduplicator{
+		window:   window,
+		maxSkew:  maxSkew,
+		capacity: capacity,
+		records:  map[string]*list.Element{},
+		queue:    list.New(),
+	}, nil
+}
+
+// Len returns the count of records
+func (dedup *MessageDeduplicator) Len() int {
+	dedup.mutex.Lock()
+	defer dedup.mutex.Unlock()
+	return len(dedup.records)
+}
+
+// CheckReliableMessage checks message time and signature,
+// it's safe to call before verifying, nothing will be recorded
+//
+// Returns: UnpackError with reason ErrDuplicated / ErrTimeOutOfRange / ErrDedupNoKey
+func (dedup *MessageDeduplicator) CheckReliableMessage(rMsg ReliableMessage) error {
+	err := dedup.Check(rMsg.Sender(), 0, reliableDigest(rMsg), rMsg.Time())
+	if err != nil {
+		return NewUnpackError(err, "reliable message rejected", rMsg)
+	}
+	return nil
+}
+
+// RecordReliableMessage records the message,
+// call it only after the signature is verified
+//
+// Returns: UnpackError with reason ErrDuplicated / ErrTimeOutOfRange / ErrDedupNoKey / ErrDedupFull
+func (dedup *MessageDeduplicator) RecordReliableMessage(rMsg ReliableMessage) error {
+	err := dedup.Record(rMsg.Sender(), 0, reliableDigest(rMsg), rMsg.Time())
+	if err != nil {
+		return NewUnpackError(err, "reliable message rejected", rMsg)
+	}
+	return nil
+}
+
+// digest(data + signature), so the same signature with other data
+// (which will fail to verify) will not be taken as duplicated
+func reliableDigest(rMsg ReliableMessage) []byte {
+	var data, signature []byte
+	if ted := rMsg.Data(); ted != nil {
+		data = ted.Bytes()
+	}
+	if ted := rMsg.Signature(); ted != nil {
+		signature = ted.Bytes()
+	}
+	if len(signature) == 0 {
+		return nil
+	}
+	hash := sha256.New()
+	hash.Write(data)
+	hash.Write(signature)
+	return hash.Sum(nil)
+}
+
+// CheckInstantMessage checks message time and content SN,
+// nothing will be recorded
+//
+// Returns: UnpackError with reason ErrDuplicated / ErrTimeOutOfRange / ErrDedupNoKey
+func (dedup *MessageDeduplicator) CheckInstantMessage(iMsg InstantMessage) error {
+	sn := contentSN(iMsg)
+	err := dedup.Check(iMsg.Sender(), sn, nil, iMsg.Time())
+	if err != nil {
+		return NewUnpackError(err, fmt.Sprintf("instant message rejected, sn: %d", sn), iMsg)
+	}
+	return nil
+}
+
+// RecordInstantMessage records the message after decrypted
+// (the reliable message must be verified before)
+//
+// Returns: UnpackError with reason ErrDuplicated / ErrTimeOutOfRange / ErrDedupNoKey / ErrDedupFull
+func (dedup *MessageDeduplicator) RecordInstantMessage(iMsg InstantMessage) error {
+	sn := contentSN(iMsg)
+	err := dedup.Record(iMsg.Sender(), sn, nil, iMsg.Time())
+	if err != nil {
+		return NewUnpackError(err, fmt.Sprintf("instant message rejected, sn: %d", sn), iMsg)
+	}
+	return nil
+}
+
+func contentSN(iMsg InstantMessage) SerialNumberType {
+	if content := iMsg.Content(); content != nil {
+		return content.SN()
+	}
+	return 0
+}
+
+// Check rejects the message if its time is out of range, or it was seen before;
+// it's read-only, call Record() to remember the message
+//
+// Parameters:
+//   - sender : message sender
+//   - sn     : content serial number (0 means unknown)
+//   - digest : digest of message data & signature (nil means unknown)
+//   - when   : message time
+//
+// Returns: ErrDuplicated / ErrTimeOutOfRange / ErrDedupNoKey
+func (dedup *MessageDeduplicator) Check(sender ID, sn SerialNumberType, digest []byte, when Time) error {
+	now := time.Now()
+	keys, err := dedup.buildKeys(sender, sn, digest, when, now)
+	if err != nil {
+		return err
+	}
+	dedup.mutex.Lock()
+	defer dedup.mutex.Unlock()
+	dedup.purge(now)
+	return dedup.checkKeys(keys)
+}
+
+// Record checks the message again and remembers it (atomically),
+// call it only after the message is verified
+//
+// Returns: ErrDuplicated / ErrTimeOutOfRange / ErrDedupNoKey / ErrDedupFull
+func (dedup *MessageDeduplicator) Record(sender ID, sn SerialNumberType, digest []byte, when Time) error {
+	now := time.Now()
+	keys, err := dedup.buildKeys(sender, sn, digest, when, now)
+	if err != nil {
+		return err
+	}
+	dedup.mutex.Lock()
+	defer dedup.mutex.Unlock()
+	dedup.purge(now)
+	if err = dedup.checkKeys(keys); err != nil {
+		return err
+	} else if len(dedup.records)+len(keys) > dedup.capacity {
+		// live records cannot be dropped, or the replays would be accepted
+		return ErrDedupFull
+	}
+	// the record must outlive the message acceptance
+	expire := now.Add(dedup.window + dedup.maxSkew)
+	for _, key := range keys {
+		dedup.records[key] = dedup.queue.PushBack(&dedupRecord{
+			key:    key,
+			expire: expire,
+		})
+	}
+	return nil
+}
+
+func (dedup *MessageDeduplicator) buildKeys(sender ID, sn SerialNumberType, digest []byte, when Time, now time.Time) ([]string, error) {
+	if sender == nil || TimeIsNil(when) {
+		return nil, ErrTimeOutOfRange
+	}
+	msgTime := time.UnixMicro(int64(TimeToFloat64(when) * 1e6))
+	if msgTime.Before(now.Add(-dedup.window)) || msgTime.After(now.Add(dedup.maxSkew)) {
+		return nil, ErrTimeOutOfRange
+	}
+	keys := make([]string, 0, 2)
+	if len(digest) > 0 {
+		keys = append(keys, sender.String()+"|sig:"+string(digest))
+	}
+	if sn > 0 {
+		keys = append(keys, sender.String()+"|sn:"+strconv.FormatUint(sn, 10))
+	}
+	if len(keys) == 0 {
+		// cannot tell the replays
+		return nil, ErrDedupNoKey
+	}
+	return keys, nil
+}
+
+func (dedup *MessageDeduplicator) checkKeys(keys []string) error {
+	for _, key := range keys {
+		if _, exists := dedup.records[key]; exists {
+			return ErrDuplicated
+		}
+	}
+	return nil
+}
+
+// remove expired records
+func (dedup *MessageDeduplicator) purge(now time.Time) {
+	for {
+		front := dedup.queue.Front()
+		if front == nil || front.Value.(*dedupRecord).expire.After(now) {
+			break
+		}
+		dedup.remove(front)
+	}
+}
+
+func (dedup *MessageDeduplicator) remove(element *list.Element) {
+	record := dedup.queue.Remove(element).(*dedupRecord)
+	delete(dedup.records, record.key)
+}
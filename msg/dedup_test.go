@@ -0,0 +1,111 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimchat/core-go/internal/testutil"
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+func TestDeduplicatorConstructor(t *testing.T) {
+	if _, err := NewMessageDeduplicator(time.Minute, time.Second, 0); err == nil {
+		t.Errorf("zero capacity should be rejected")
+	}
+	if _, err := NewMessageDeduplicator(0, time.Second, 16); err == nil {
+		t.Errorf("zero window should be rejected")
+	}
+}
+
+func TestDeduplicatorCheckAndRecord(t *testing.T) {
+	dedup, err := NewMessageDeduplicator(time.Minute, time.Second, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := testutil.IDFromString("moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk")
+	now := TimeNow()
+
+	// check only, nothing recorded (e.g.: before verifying)
+	if err = dedup.Check(sender, 1, []byte("sig1"), now); err != nil {
+		t.Fatal(err)
+	} else if err = dedup.Check(sender, 1, []byte("sig1"), now); err != nil {
+		t.Errorf("check should not record: %v", err)
+	} else if dedup.Len() != 0 {
+		t.Errorf("records: %d", dedup.Len())
+	}
+
+	// record after verified
+	if err = dedup.Record(sender, 1, []byte("sig1"), now); err != nil {
+		t.Fatal(err)
+	} else if err = dedup.Check(sender, 0, []byte("sig1"), now); !errors.Is(err, ErrDuplicated) {
+		t.Errorf("replay should be rejected: %v", err)
+	} else if err = dedup.Record(sender, 1, nil, now); !errors.Is(err, ErrDuplicated) {
+		t.Errorf("replay should be rejected: %v", err)
+	}
+
+	// full: live records are kept, new message rejected
+	if err = dedup.Record(sender, 2, nil, now); !errors.Is(err, ErrDedupFull) {
+		t.Errorf("should be full: %v", err)
+	} else if err = dedup.Check(sender, 1, nil, now); !errors.Is(err, ErrDuplicated) {
+		t.Errorf("live record should not be evicted: %v", err)
+	}
+
+	// time out of range
+	old := TimeFromFloat64(TimeToFloat64(now) - 120)
+	if err = dedup.Check(sender, 3, nil, old); !errors.Is(err, ErrTimeOutOfRange) {
+		t.Errorf("old message should be rejected: %v", err)
+	}
+	future := TimeFromFloat64(TimeToFloat64(now) + 60)
+	if err = dedup.Check(sender, 3, nil, future); !errors.Is(err, ErrTimeOutOfRange) {
+		t.Errorf("future message should be rejected: %v", err)
+	}
+}
+
+func TestDeduplicatorNoKey(t *testing.T) {
+	dedup, err := NewMessageDeduplicator(time.Minute, time.Second, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := testutil.IDFromString("moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk")
+	now := TimeNow()
+	// no signature & no serial number, the replays cannot be told
+	if err = dedup.Check(sender, 0, nil, now); !errors.Is(err, ErrDedupNoKey) {
+		t.Errorf("message without key should be rejected: %v", err)
+	} else if err = dedup.Record(sender, 0, nil, now); !errors.Is(err, ErrDedupNoKey) {
+		t.Errorf("message without key should be rejected: %v", err)
+	} else if dedup.Len() != 0 {
+		t.Errorf("records: %d", dedup.Len())
+	}
+}
@@ -0,0 +1,84 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/protocol"
+)
+
+// InstantMessageDelegate supplies keys for encrypting instant messages
+type InstantMessageDelegate interface {
+
+	// GetPublicKeyForEncryption returns the public key to encrypt message key for receiver
+	//
+	// Usually it's the key in receiver's visa document (or the key in meta)
+	//
+	// Parameters:
+	//   - receiver: User ID (or group member ID)
+	// Returns: Encrypt key (nil if not found)
+	GetPublicKeyForEncryption(receiver ID) EncryptKey
+}
+
+// SecureMessageDelegate supplies keys for decrypting & signing secure messages
+type SecureMessageDelegate interface {
+
+	// GetPrivateKeysForDecryption returns the private keys to decrypt message key for receiver
+	//
+	// Usually they are the private keys paired with the keys in receiver's visa & meta
+	//
+	// Parameters:
+	//   - receiver: Local user ID
+	// Returns: Decrypt keys (empty if not found)
+	GetPrivateKeysForDecryption(receiver ID) []DecryptKey
+
+	// GetPrivateKeyForSignature returns the private key to sign message data for sender
+	//
+	// Usually it's the private key paired with the key in sender's meta
+	//
+	// Parameters:
+	//   - sender: Local user ID
+	// Returns: Sign key (nil if not found)
+	GetPrivateKeyForSignature(sender ID) SignKey
+}
+
+// ReliableMessageDelegate supplies keys for verifying reliable messages
+type ReliableMessageDelegate interface {
+
+	// GetPublicKeysForVerification returns the public keys to verify message signature for sender
+	//
+	// Usually they are the key in sender's meta and the key in sender's visa document
+	//
+	// Parameters:
+	//   - sender: User ID
+	// Returns: Verify keys (empty if not found)
+	GetPublicKeysForVerification(sender ID) []VerifyKey
+}
@@ -0,0 +1,85 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/digest"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/*
+ *  Key Digest
+ *  ~~~~~~~~~~
+ *  digest = base64_encode(sha256(pwd.data[-6:])[-8:])
+ *
+ *  the digest of message key will be put in 'keys' as "digest",
+ *  so the receiver can check whether the key is reused.
+ */
+
+// GetKeyDigest returns the digest of the symmetric key data
+//
+// Returns: base64 of the last 8 bytes of sha256(last 6 bytes of key data),
+// empty string if key data too short
+func GetKeyDigest(password SymmetricKey) string {
+	ted := password.Data()
+	if ted == nil {
+		return ""
+	}
+	data := ted.Bytes()
+	if len(data) < 6 {
+		return ""
+	}
+	// get digest for the last 6 bytes of key.data
+	dig := SHA256(data[len(data)-6:])
+	// get last 8 bytes as key digest
+	dig = dig[len(dig)-8:]
+	return Base64Encode(dig)
+}
+
+// MatchKeyDigest checks the key digest in message with the symmetric key
+//
+// Returns: false when digest exists but not match the key
+func MatchKeyDigest(sMsg SecureMessage, password SymmetricKey) bool {
+	var digest string
+	if msg, ok := sMsg.(interface{ KeyDigest() string }); ok {
+		digest = msg.KeyDigest()
+	} else if keys := sMsg.EncryptedKeys(); keys != nil {
+		digest = ConvertString(keys["digest"], "")
+	}
+	if digest == "" {
+		// digest not found
+		return true
+	}
+	return digest == GetKeyDigest(password)
+}
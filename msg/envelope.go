@@ -162,3 +162,87 @@ func (env *MessageEnvelope) Type() MessageType {
 func (env *MessageEnvelope) SetType(msgType MessageType) {
 	env.Set("type", msgType)
 }
+
+/*
+ *  Compression
+ *  ~~~~~~~~~~~
+ *  the algorithm for compressing the serialized content before encrypted,
+ *  empty means the content is not compressed.
+ */
+
+func (env *MessageEnvelope) Compression() string {
+	return env.GetString("compression", "")
+}
+
+func (env *MessageEnvelope) SetCompression(algorithm string) {
+	if algorithm == "" {
+		env.Remove("compression")
+	} else {
+		env.Set("compression", algorithm)
+	}
+}
+
+/*
+ *  Expiry & Priority
+ *  ~~~~~~~~~~~~~~~~~
+ *  messages like typing indicators or call offers are worthless after
+ *  a few seconds, relays and clients should drop them when expired
+ *  instead of keeping them in offline queues forever;
+ *  and the 'priority' helps them to order the queues.
+ */
+
+// Delivery priority, smaller value will be delivered first
+const (
+	PRIORITY_URGENT = -1
+	PRIORITY_NORMAL = 0
+	PRIORITY_SLOWER = 1
+)
+
+// Expires returns the expiry time (nil means never expires)
+func (env *MessageEnvelope) Expires() Time {
+	return env.GetTime("expires", nil)
+}
+
+func (env *MessageEnvelope) SetExpires(when Time) {
+	if TimeIsNil(when) {
+		env.Remove("expires")
+	} else {
+		env.SetTime("expires", when)
+	}
+}
+
+// SetTTL sets expiry time with seconds after the message time
+func (env *MessageEnvelope) SetTTL(seconds float64) {
+	when := env.Time()
+	if TimeIsNil(when) {
+		when = TimeNow()
+	}
+	env.SetExpires(TimeFromFloat64(TimeToFloat64(when) + seconds))
+}
+
+// IsExpired checks whether the message is stale at 'now'
+func (env *MessageEnvelope) IsExpired(now Time) bool {
+	return isExpired(env.Expires(), now)
+}
+
+func (env *MessageEnvelope) Priority() int {
+	return env.GetInt("priority", PRIORITY_NORMAL)
+}
+
+func (env *MessageEnvelope) SetPriority(priority int) {
+	if priority == PRIORITY_NORMAL {
+		env.Remove("priority")
+	} else {
+		env.Set("priority", priority)
+	}
+}
+
+func isExpired(expires, now Time) bool {
+	if TimeIsNil(expires) {
+		// never expires
+		return false
+	} else if TimeIsNil(now) {
+		now = TimeNow()
+	}
+	return TimeToFloat64(expires) < TimeToFloat64(now)
+}
@@ -0,0 +1,127 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+func TestEnvelopeExpires(t *testing.T) {
+	env := NewMessageEnvelope(nil, moki, hulk, TimeFromFloat64(1000))
+	if !TimeIsNil(env.Expires()) {
+		t.Errorf("expires should be empty: %v", env.Expires())
+	} else if env.IsExpired(TimeFromFloat64(1e9)) {
+		t.Errorf("message without 'expires' should never expire")
+	}
+
+	// TTL starts from the message time, not now
+	env.SetTTL(30)
+	if expires := TimeToFloat64(env.Expires()); expires != 1030 {
+		t.Errorf("expires error: %v", expires)
+	} else if env.IsExpired(TimeFromFloat64(1030)) {
+		t.Errorf("message should not expire at the deadline")
+	} else if !env.IsExpired(TimeFromFloat64(1031)) {
+		t.Errorf("message should expire after the deadline")
+	} else if !env.IsExpired(nil) {
+		t.Errorf("message should expire now")
+	}
+
+	env.SetExpires(nil)
+	if env.Contains("expires") {
+		t.Errorf("'expires' should be removed")
+	}
+}
+
+func TestEnvelopePriority(t *testing.T) {
+	env := NewMessageEnvelope(nil, moki, hulk, nil)
+	if env.Priority() != PRIORITY_NORMAL {
+		t.Errorf("default priority error: %d", env.Priority())
+	}
+	env.SetPriority(PRIORITY_URGENT)
+	if env.Priority() != PRIORITY_URGENT {
+		t.Errorf("priority error: %d", env.Priority())
+	}
+	env.SetPriority(PRIORITY_NORMAL)
+	if env.Contains("priority") {
+		t.Errorf("normal priority should not be stored")
+	}
+}
+
+func newQueuedMessage(name string, when float64, priority int, expires float64) ReliableMessage {
+	info := StringKeyMap{
+		"sender":    moki.String(),
+		"receiver":  hulk.String(),
+		"time":      when,
+		"data":      name,
+		"signature": "",
+	}
+	if priority != PRIORITY_NORMAL {
+		info["priority"] = priority
+	}
+	if expires > 0 {
+		info["expires"] = expires
+	}
+	return NewReliableMessageWithMap(info)
+}
+
+func TestIsExpiredMessage(t *testing.T) {
+	now := TimeFromFloat64(2000)
+	if IsExpiredMessage(newQueuedMessage("forever", 1000, PRIORITY_NORMAL, 0), now) {
+		t.Errorf("message without 'expires' should never expire")
+	} else if IsExpiredMessage(newQueuedMessage("alive", 1000, PRIORITY_NORMAL, 3000), now) {
+		t.Errorf("message should be alive")
+	} else if !IsExpiredMessage(newQueuedMessage("stale", 1000, PRIORITY_NORMAL, 1500), now) {
+		t.Errorf("message should expire")
+	}
+}
+
+func TestMessageLess(t *testing.T) {
+	queue := []ReliableMessage{
+		newQueuedMessage("slower", 1000, PRIORITY_SLOWER, 0),
+		newQueuedMessage("normal-2", 1002, PRIORITY_NORMAL, 0),
+		newQueuedMessage("urgent", 1003, PRIORITY_URGENT, 0),
+		newQueuedMessage("normal-1", 1001, PRIORITY_NORMAL, 0),
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return MessageLess(queue[i], queue[j])
+	})
+	expected := []string{"urgent", "normal-1", "normal-2", "slower"}
+	for i, msg := range queue {
+		if name := msg.GetString("data", ""); name != expected[i] {
+			t.Errorf("queue[%d] error: %s, expected: %s", i, name, expected[i])
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/dimchat/dkd-go/protocol"
+)
+
+// Reasons for failing to unpack a message
+//
+// Check them with errors.Is(err, ErrBadSignature), ...
+var (
+	ErrBadSignature   = errors.New("signature not match")
+	ErrKeyMissing     = errors.New("message key missing")
+	ErrDecryptFailed  = errors.New("failed to decrypt")
+	ErrDecompress     = errors.New("failed to decompress")
+	ErrContentParse   = errors.New("failed to parse content")
+	ErrDuplicated     = errors.New("duplicated message")
+	ErrDedupFull      = errors.New("too many messages to deduplicate")
+	ErrDedupNoKey     = errors.New("no signature or serial number to deduplicate")
+	ErrTimeOutOfRange = errors.New("message time out of range")
+	ErrRelayLoop      = errors.New("message relayed in loop")
+	ErrInvalidMeta    = errors.New("invalid meta")
+	ErrInvalidVisa    = errors.New("invalid visa")
+)
+
+// UnpackError describes which stage of unpacking failed, and for which message
+type UnpackError struct {
+
+	// Reason is one of the errors above (ErrBadSignature, ErrKeyMissing, ...)
+	Reason error
+
+	// Detail describes the failure
+	Detail string
+
+	// Msg is the message failed to unpack
+	Msg Message
+}
+
+func NewUnpackError(reason error, detail string, msg Message) *UnpackError {
+	return &UnpackError{
+		Reason: reason,
+		Detail: detail,
+		Msg:    msg,
+	}
+}
+
+// Override
+func (err *UnpackError) Error() string {
+	msg := err.Msg
+	if msg == nil {
+		return fmt.Sprintf("%v: %s", err.Reason, err.Detail)
+	}
+	return fmt.Sprintf("%v: %s, %v -> %v", err.Reason, err.Detail, msg.Sender(), msg.Receiver())
+}
+
+// Unwrap returns the reason for errors.Is()
+func (err *UnpackError) Unwrap() error {
+	return err.Reason
+}
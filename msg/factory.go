@@ -0,0 +1,162 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"math/rand"
+	"sync"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Envelope Factory
+ */
+
+type MessageEnvelopeFactory struct {
+	//EnvelopeFactory
+}
+
+// Override
+func (MessageEnvelopeFactory) CreateEnvelope(from, to ID, when Time) Envelope {
+	return NewMessageEnvelope(nil, from, to, when)
+}
+
+// Override
+func (MessageEnvelopeFactory) ParseEnvelope(env StringKeyMap) Envelope {
+	// check 'sender'
+	if _, exists := env["sender"]; !exists {
+		// env.sender should not be empty
+		return nil
+	}
+	return NewMessageEnvelope(env, nil, nil, nil)
+}
+
+/**
+ *  Message Factory
+ */
+
+type MessageFactory struct {
+	//InstantMessageFactory
+	//SecureMessageFactory
+	//ReliableMessageFactory
+
+	sn    SerialNumberType
+	mutex sync.Mutex
+}
+
+func NewMessageFactory() *MessageFactory {
+	// random start
+	sn := SerialNumberType(rand.Uint32() & 0x7fffffff)
+	return &MessageFactory{
+		sn: sn,
+	}
+}
+
+// next returns a serial number in range (0, 0x7fffffff]
+func (factory *MessageFactory) next() SerialNumberType {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	sn := factory.sn
+	if sn < 0x7fffffff {
+		sn += 1
+	} else {
+		sn = 1
+	}
+	factory.sn = sn
+	return sn
+}
+
+//
+//  InstantMessageFactory
+//
+
+// Override
+func (factory *MessageFactory) GenerateSerialNumber(_ MessageType, _ Time) SerialNumberType {
+	// because we must make sure all messages in a same chat box won't have
+	// same serial numbers, so we can't use time-related numbers, therefore
+	// the best choice is a totally random number, maybe.
+	return factory.next()
+}
+
+// Override
+func (factory *MessageFactory) CreateInstantMessage(head Envelope, body Content) InstantMessage {
+	return NewPlainMessage(nil, head, body)
+}
+
+// Override
+func (factory *MessageFactory) ParseInstantMessage(msg StringKeyMap) InstantMessage {
+	// check 'sender', 'content'
+	if _, exists := msg["sender"]; !exists {
+		return nil
+	} else if _, exists = msg["content"]; !exists {
+		return nil
+	}
+	return NewPlainMessage(msg, nil, nil)
+}
+
+//
+//  SecureMessageFactory
+//
+
+// Override
+func (factory *MessageFactory) ParseSecureMessage(msg StringKeyMap) SecureMessage {
+	// check 'sender', 'data'
+	if _, exists := msg["sender"]; !exists {
+		return nil
+	} else if _, exists = msg["data"]; !exists {
+		return nil
+	}
+	// check 'signature'
+	if _, exists := msg["signature"]; exists {
+		return NewNetworkMessage(msg, nil, nil)
+	}
+	return NewEncryptedMessage(msg, nil)
+}
+
+//
+//  ReliableMessageFactory
+//
+
+// Override
+func (factory *MessageFactory) ParseReliableMessage(msg StringKeyMap) ReliableMessage {
+	// check 'sender', 'data', 'signature'
+	if _, exists := msg["sender"]; !exists {
+		return nil
+	} else if _, exists = msg["data"]; !exists {
+		return nil
+	} else if _, exists = msg["signature"]; !exists {
+		return nil
+	}
+	return NewNetworkMessage(msg, nil, nil)
+}
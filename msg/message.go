@@ -167,3 +167,28 @@ func IsBroadcastMessage(msg Message) bool {
 	group := ParseID(overtGroup)
 	return group != nil && group.IsBroadcast()
 }
+
+// IsExpiredMessage checks the 'expires' field of message envelope
+//
+// Parameters:
+//   - msg : any message (instant/secure/reliable)
+//   - now : current time (nil means TimeNow())
+func IsExpiredMessage(msg Message, now Time) bool {
+	expires := msg.GetTime("expires", nil)
+	return isExpired(expires, now)
+}
+
+// GetMessagePriority returns the delivery priority in message envelope
+func GetMessagePriority(msg Message) int {
+	return msg.GetInt("priority", PRIORITY_NORMAL)
+}
+
+// MessageLess orders messages in queue: higher priority first,
+// and then older message first
+func MessageLess(a, b Message) bool {
+	pa, pb := GetMessagePriority(a), GetMessagePriority(b)
+	if pa != pb {
+		return pa < pb
+	}
+	return a.GetFloat64("time", 0) < b.GetFloat64("time", 0)
+}
@@ -0,0 +1,512 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	"fmt"
+
+	. "github.com/dimchat/core-go/crypto"
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Instant Message Packer
+ *  ~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  Algorithm:
+ *      data = password.encrypt(content)
+ *      key  = receiver.public_key.encrypt(password)
+ */
+
+type InstantMessagePacker struct {
+	delegate InstantMessageDelegate
+
+	// compressor for content larger than threshold (nil means disabled)
+	compressor        Compressor
+	compressThreshold int
+}
+
+func NewInstantMessagePacker(delegate InstantMessageDelegate) *InstantMessagePacker {
+	return &InstantMessagePacker{
+		delegate: delegate,
+	}
+}
+
+func (packer *InstantMessagePacker) Delegate() InstantMessageDelegate {
+	return packer.delegate
+}
+
+// EnableCompression compresses content before encrypting
+//
+// Parameters:
+//   - compressor : algorithm negotiated with the receiver (nil to disable)
+//   - threshold  : min size of serialized content to be compressed
+func (packer *InstantMessagePacker) EnableCompression(compressor Compressor, threshold int) {
+	packer.compressor = compressor
+	packer.compressThreshold = threshold
+}
+
+// Encrypt converts an instant message to secure message
+//
+// Encrypts 'message.content' to 'message.data' with the symmetric key,
+// and encrypts the symmetric key with the public key of each receiver.
+//
+// Broadcast message will always use the PLAIN key, its content is just
+// serialized to JsON (readable for everyone), and no key will be attached.
+//
+// Parameters:
+//   - iMsg     : plain message
+//   - password : symmetric key to encrypt the content
+//   - members  : group members for group message (nil means personal message)
+//
+// Returns: encrypted message (nil on failed, or when no public key
+// for encryption found for any of the receivers),
+// and the receivers whose public keys not found (they cannot decrypt
+// this message, the application should send it again after their visa
+// documents are received)
+func (packer *InstantMessagePacker) Encrypt(iMsg InstantMessage, password SymmetricKey, members []ID) (sMsg *EncryptedMessage, missed []ID) {
+	broadcast := IsBroadcastMessage(iMsg)
+	if broadcast {
+		// broadcast message content will not be encrypted
+		password = GetPlainKey()
+	}
+	// 0. check attachment for File/Image/Audio/Video message content
+	//    (do it by application)
+
+	// 1. Serialize 'message.content' to data (JsON / ProtoBuf / ...)
+	body := packer.SerializeContent(iMsg.Content(), password, iMsg)
+	if len(body) == 0 {
+		//panic("failed to serialize content")
+		return nil, nil
+	}
+	// 1.1. Compress content data (optional)
+	body, compression := packer.CompressContent(body, iMsg)
+	// 2. Encrypt content data to 'message.data' with symmetric key
+	ciphertext := password.Encrypt(body, iMsg.Map())
+	if len(ciphertext) == 0 {
+		//panic("failed to encrypt content")
+		return nil, nil
+	}
+	// 3. Encode 'message.data' to String (Base64)
+	var encodedData any
+	if broadcast {
+		// broadcast message content will not be encrypted (just encoded to JsON),
+		// so no need to encode to Base64 here
+		encodedData = UTF8Decode(ciphertext)
+	} else {
+		// message content had been encrypted by a symmetric key,
+		// so the data should be encoded here (with algorithm 'base64' as default).
+		encodedData = NewBase64DataWithBytes(ciphertext).Serialize()
+	}
+	// replace 'content' with encrypted 'data'
+	info := iMsg.CopyMap(false)
+	delete(info, "content")
+	info["data"] = encodedData
+	if compression != "" {
+		info["compression"] = compression
+	}
+	if broadcast {
+		// broadcast message needs no key
+		return NewEncryptedMessage(info, nil), nil
+	}
+
+	// 4. Serialize message key to data (JsON / ProtoBuf / ...)
+	pwd := packer.SerializeKey(password, iMsg)
+	if len(pwd) == 0 {
+		//panic("failed to serialize message key")
+		return nil, nil
+	}
+	if members == nil {
+		// personal message
+		members = []ID{iMsg.Receiver()}
+	}
+	// 5. Encrypt key data to 'message.keys' with receivers' public keys
+	keys := NewMap()
+	var encryptedKey []byte
+	for _, receiver := range members {
+		encryptedKey = packer.EncryptKey(pwd, receiver, iMsg)
+		if len(encryptedKey) == 0 {
+			// public key for encryption not found
+			missed = append(missed, receiver)
+			continue
+		}
+		keys[receiver.String()] = NewBase64DataWithBytes(encryptedKey).Serialize()
+	}
+	if len(keys) == 0 {
+		// no receiver could decrypt this message
+		return nil, missed
+	}
+	// put key digest
+	digest := GetKeyDigest(password)
+	if digest != "" {
+		keys["digest"] = digest
+	}
+	info["keys"] = keys
+
+	// OK, pack message
+	return NewEncryptedMessage(info, nil), missed
+}
+
+// protected
+func (packer *InstantMessagePacker) SerializeContent(content Content, _ SymmetricKey, _ InstantMessage) []byte {
+	if content == nil {
+		return nil
+	}
+	json := JSONEncodeMap(content.Map())
+	return UTF8Encode(json)
+}
+
+// protected
+func (packer *InstantMessagePacker) CompressContent(body []byte, iMsg InstantMessage) ([]byte, string) {
+	compressor := packer.compressor
+	if compressor == nil || len(body) < packer.compressThreshold {
+		return body, ""
+	} else if IsBroadcastMessage(iMsg) {
+		// broadcast message content must be readable text
+		return body, ""
+	}
+	data := compressor.Compress(body)
+	if len(data) == 0 || len(data) >= len(body) {
+		// not worth it
+		return body, ""
+	}
+	return data, compressor.Algorithm()
+}
+
+// protected
+func (packer *InstantMessagePacker) SerializeKey(password SymmetricKey, _ InstantMessage) []byte {
+	json := JSONEncodeMap(password.Map())
+	return UTF8Encode(json)
+}
+
+// protected
+func (packer *InstantMessagePacker) EncryptKey(key []byte, receiver ID, iMsg InstantMessage) []byte {
+	pKey := packer.delegate.GetPublicKeyForEncryption(receiver)
+	if pKey == nil {
+		//panic("failed to get encrypt key for receiver: " + receiver.String())
+		return nil
+	}
+	return pKey.Encrypt(key, iMsg.Map())
+}
+
+/**
+ *  Secure Message Packer
+ *  ~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  Algorithm:
+ *      password  = receiver.private_key.decrypt(key)
+ *      content   = password.decrypt(data)
+ *      signature = sender.private_key.sign(data)
+ */
+
+type SecureMessagePacker struct {
+	delegate SecureMessageDelegate
+
+	// max size of decompressed content
+	maxContentSize int
+}
+
+func NewSecureMessagePacker(delegate SecureMessageDelegate) *SecureMessagePacker {
+	return &SecureMessagePacker{
+		delegate:       delegate,
+		maxContentSize: MAX_DECOMPRESSED_SIZE,
+	}
+}
+
+func (packer *SecureMessagePacker) Delegate() SecureMessageDelegate {
+	return packer.delegate
+}
+
+func (packer *SecureMessagePacker) SetMaxContentSize(size int) {
+	packer.maxContentSize = size
+}
+
+// Decrypt converts a secure message to instant message
+//
+// Decrypts the message key with the private key of receiver,
+// and then decrypts 'message.data' to 'message.content' with it.
+//
+// Parameters:
+//   - sMsg     : encrypted message
+//   - receiver : local user ID (the member of group message)
+//
+// Returns: plain message, or UnpackError with reason:
+// ErrKeyMissing, ErrDecryptFailed, ErrDecompress, ErrContentParse
+func (packer *SecureMessagePacker) Decrypt(sMsg SecureMessage, receiver ID) (*PlainMessage, error) {
+	var password SymmetricKey
+	if IsBroadcastMessage(sMsg) {
+		// broadcast message content is not encrypted
+		password = GetPlainKey()
+	} else {
+		// 1~3. Get message key for receiver
+		key, err := packer.DecryptKey(sMsg, receiver)
+		if err != nil {
+			return nil, err
+		}
+		password = key
+	}
+
+	// 4. Decode 'message.data' to encrypted content data
+	ted := sMsg.Data()
+	if ted == nil {
+		return nil, NewUnpackError(ErrDecryptFailed, "message data not found", sMsg)
+	}
+	ciphertext := ted.Bytes()
+	if len(ciphertext) == 0 {
+		return nil, NewUnpackError(ErrDecryptFailed, "failed to decode message data", sMsg)
+	}
+	// 5. Decrypt 'message.data' with symmetric key
+	body := password.Decrypt(ciphertext, sMsg.Map())
+	if len(body) == 0 {
+		return nil, NewUnpackError(ErrDecryptFailed, "failed to decrypt message data", sMsg)
+	}
+	// 5.1. Decompress content data (if compressed)
+	body, err := packer.DecompressContent(body, sMsg)
+	if err != nil {
+		return nil, NewUnpackError(ErrDecompress, err.Error(), sMsg)
+	}
+	// 6. Deserialize message content from data (JsON / ProtoBuf / ...)
+	content := packer.DeserializeContent(body, password, sMsg)
+	if content == nil {
+		return nil, NewUnpackError(ErrContentParse, "failed to deserialize content", sMsg)
+	}
+
+	// 7. pack message
+	info := sMsg.CopyMap(false)
+	delete(info, "key")
+	delete(info, "keys")
+	delete(info, "data")
+	delete(info, "compression")
+	info["content"] = content.Map()
+	return NewPlainMessage(info, nil, content), nil
+}
+
+// protected
+func (packer *SecureMessagePacker) DecryptKey(sMsg SecureMessage, receiver ID) (SymmetricKey, error) {
+	// 1. Decode 'message.key' to encrypted symmetric key data
+	encryptedKey := packer.getEncryptedKey(sMsg, receiver)
+	if len(encryptedKey) == 0 {
+		return nil, NewUnpackError(ErrKeyMissing, "encrypted key not found for "+receiver.String(), sMsg)
+	}
+	// 2. Decrypt 'message.key' with receiver's private key
+	decryptKeys := packer.delegate.GetPrivateKeysForDecryption(receiver)
+	if len(decryptKeys) == 0 {
+		return nil, NewUnpackError(ErrKeyMissing, "decrypt keys not found for "+receiver.String(), sMsg)
+	}
+	var keyData []byte
+	for _, sKey := range decryptKeys {
+		keyData = sKey.Decrypt(encryptedKey, sMsg.Map())
+		if len(keyData) > 0 {
+			// decrypted
+			break
+		}
+	}
+	if len(keyData) == 0 {
+		return nil, NewUnpackError(ErrDecryptFailed, "failed to decrypt message key", sMsg)
+	}
+	// 3. Deserialize message key from data (JsON / ProtoBuf / ...)
+	password := packer.DeserializeKey(keyData, sMsg)
+	if password == nil {
+		return nil, NewUnpackError(ErrDecryptFailed, "failed to deserialize message key", sMsg)
+	} else if !MatchKeyDigest(sMsg, password) {
+		return nil, NewUnpackError(ErrDecryptFailed, "message key digest not match", sMsg)
+	}
+	return password, nil
+}
+
+// protected
+func (packer *SecureMessagePacker) getEncryptedKey(sMsg SecureMessage, receiver ID) []byte {
+	var ted TransportableData
+	if msg, ok := sMsg.(interface {
+		EncryptedKey(receiver ID) TransportableData
+	}); ok {
+		ted = msg.EncryptedKey(receiver)
+	} else if keys := sMsg.EncryptedKeys(); keys != nil {
+		ted = ParseTransportableData(keys[receiver.String()])
+	}
+	if ted == nil {
+		return nil
+	}
+	return ted.Bytes()
+}
+
+// protected
+func (packer *SecureMessagePacker) DeserializeKey(key []byte, _ SecureMessage) SymmetricKey {
+	json := UTF8Decode(key)
+	dict := JSONDecodeMap(json)
+	if dict == nil {
+		return nil
+	}
+	return ParseSymmetricKey(dict)
+}
+
+// protected
+func (packer *SecureMessagePacker) DecompressContent(body []byte, sMsg SecureMessage) ([]byte, error) {
+	algorithm := sMsg.GetString("compression", "")
+	if algorithm == "" {
+		// not compressed
+		return body, nil
+	}
+	compressor := GetCompressor(algorithm)
+	if compressor == nil {
+		return nil, fmt.Errorf("compression not supported: %s", algorithm)
+	}
+	return compressor.Decompress(body, packer.maxContentSize)
+}
+
+// protected
+func (packer *SecureMessagePacker) DeserializeContent(data []byte, _ SymmetricKey, _ SecureMessage) Content {
+	json := UTF8Decode(data)
+	dict := JSONDecodeMap(json)
+	if dict == nil {
+		return nil
+	}
+	return ParseContent(dict)
+}
+
+// Sign converts a secure message to reliable message
+//
+// Signs 'message.data' with the private key of sender
+//
+// Parameters:
+//   - sMsg : encrypted message
+//
+// Returns: signed message (nil on failed)
+func (packer *SecureMessagePacker) Sign(sMsg SecureMessage) *NetworkMessage {
+	// 0. decode message data
+	ted := sMsg.Data()
+	if ted == nil {
+		//panic("message data not found")
+		return nil
+	}
+	ciphertext := ted.Bytes()
+	if len(ciphertext) == 0 {
+		//panic("failed to decode message data")
+		return nil
+	}
+	// 1. Sign 'message.data' with sender's private key
+	sKey := packer.delegate.GetPrivateKeyForSignature(sMsg.Sender())
+	if sKey == nil {
+		//panic("failed to get sign key for sender: " + sMsg.Sender().String())
+		return nil
+	}
+	signature := sKey.Sign(ciphertext)
+	if len(signature) == 0 {
+		//panic("failed to sign message data")
+		return nil
+	}
+	// 2. Encode 'message.signature' to String (Base64)
+	info := sMsg.CopyMap(false)
+	info["signature"] = NewBase64DataWithBytes(signature).Serialize()
+
+	// OK, pack message
+	return NewNetworkMessage(info, nil, nil)
+}
+
+/**
+ *  Reliable Message Packer
+ *  ~~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  Algorithm:
+ *      sender.public_key.verify(data, signature)
+ */
+
+type ReliableMessagePacker struct {
+	delegate ReliableMessageDelegate
+}
+
+func NewReliableMessagePacker(delegate ReliableMessageDelegate) *ReliableMessagePacker {
+	return &ReliableMessagePacker{
+		delegate: delegate,
+	}
+}
+
+func (packer *ReliableMessagePacker) Delegate() ReliableMessageDelegate {
+	return packer.delegate
+}
+
+// Verify converts a reliable message to secure message
+//
+// Verifies 'message.data' and 'message.signature' with the public keys of sender
+// (from meta or visa document)
+//
+// Parameters:
+//   - rMsg : signed message
+//
+// Returns: encrypted message, or UnpackError with reason:
+// ErrBadSignature, ErrKeyMissing
+func (packer *ReliableMessagePacker) Verify(rMsg ReliableMessage) (*EncryptedMessage, error) {
+	// 0. Decode 'message.data' to encrypted content data
+	ted := rMsg.Data()
+	if ted == nil {
+		return nil, NewUnpackError(ErrBadSignature, "message data not found", rMsg)
+	}
+	ciphertext := ted.Bytes()
+	if len(ciphertext) == 0 {
+		return nil, NewUnpackError(ErrBadSignature, "failed to decode message data", rMsg)
+	}
+	// 1. Decode 'message.signature' from String (Base64)
+	ted = rMsg.Signature()
+	if ted == nil {
+		return nil, NewUnpackError(ErrBadSignature, "message signature not found", rMsg)
+	}
+	signature := ted.Bytes()
+	if len(signature) == 0 {
+		return nil, NewUnpackError(ErrBadSignature, "failed to decode message signature", rMsg)
+	}
+	// 2. Verify the message data and signature with sender's public keys
+	sender := rMsg.Sender()
+	verifyKeys := packer.delegate.GetPublicKeysForVerification(sender)
+	if len(verifyKeys) == 0 {
+		return nil, NewUnpackError(ErrKeyMissing, "verify keys not found for "+sender.String(), rMsg)
+	}
+	matched := false
+	for _, pKey := range verifyKeys {
+		if pKey.Verify(ciphertext, signature) {
+			// signature matched
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil, NewUnpackError(ErrBadSignature, "signature not match for "+sender.String(), rMsg)
+	}
+
+	// OK, pack message
+	info := rMsg.CopyMap(false)
+	delete(info, "signature")
+	return NewEncryptedMessage(info, nil), nil
+}
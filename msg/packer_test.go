@@ -0,0 +1,196 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dimchat/core-go/internal/testutil"
+
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// keyStore holds the key pairs of local & remote users
+type keyStore struct {
+	privateKeys map[string]*testutil.StubPrivateKey
+	publicKeys  map[string]*testutil.StubPublicKey
+}
+
+func newKeyStore(users ...ID) *keyStore {
+	store := &keyStore{
+		privateKeys: map[string]*testutil.StubPrivateKey{},
+		publicKeys:  map[string]*testutil.StubPublicKey{},
+	}
+	for _, user := range users {
+		sKey, pKey := testutil.NewKeyPair(user.String())
+		store.privateKeys[user.String()] = sKey
+		store.publicKeys[user.String()] = pKey
+	}
+	return store
+}
+
+func (store *keyStore) GetPublicKeyForEncryption(receiver ID) EncryptKey {
+	if pKey := store.publicKeys[receiver.String()]; pKey != nil {
+		return pKey
+	}
+	return nil
+}
+
+func (store *keyStore) GetPrivateKeysForDecryption(receiver ID) []DecryptKey {
+	if sKey := store.privateKeys[receiver.String()]; sKey != nil {
+		return []DecryptKey{sKey}
+	}
+	return nil
+}
+
+func (store *keyStore) GetPrivateKeyForSignature(sender ID) SignKey {
+	if sKey := store.privateKeys[sender.String()]; sKey != nil {
+		return sKey
+	}
+	return nil
+}
+
+func (store *keyStore) GetPublicKeysForVerification(sender ID) []VerifyKey {
+	if pKey := store.publicKeys[sender.String()]; pKey != nil {
+		return []VerifyKey{pKey}
+	}
+	return nil
+}
+
+var (
+	moki  = testutil.IDFromString("moki@4WDfe3zZ4T7opFSi3iDAKiuTnUHjxmXekk")
+	hulk  = testutil.IDFromString("hulk@4YeVEN3aUnvC1DNUufCq1bs9zoBSJTzVEj")
+	frank = testutil.IDFromString("frank@4Qiq8CJ25Q6E3Kw9AfrhqPjSKJfVg3bDf1")
+	group = testutil.IDFromString("group@Gh3Uz7HaGKC8xaHe5x3dzNgdTBX9AyDfN")
+)
+
+// pack: encrypt & sign
+func pack(t *testing.T, store *keyStore, iMsg InstantMessage, members []ID) (ReliableMessage, []ID) {
+	password := testutil.NewXORKey([]byte("0123456789abcdef"))
+	sMsg, missed := NewInstantMessagePacker(store).Encrypt(iMsg, password, members)
+	if sMsg == nil {
+		t.Fatalf("failed to encrypt message, missed: %v", missed)
+	}
+	rMsg := NewSecureMessagePacker(store).Sign(sMsg)
+	if rMsg == nil {
+		t.Fatalf("failed to sign message")
+	}
+	return rMsg, missed
+}
+
+// unpack: verify & decrypt
+func unpack(store *keyStore, rMsg ReliableMessage, receiver ID) (InstantMessage, error) {
+	sMsg, err := NewReliableMessagePacker(store).Verify(rMsg)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecureMessagePacker(store).Decrypt(sMsg, receiver)
+}
+
+func TestPackerRoundTrip(t *testing.T) {
+	store := newKeyStore(moki, hulk)
+	text := "Hello, world!"
+	iMsg := NewInstantMessage(NewEnvelope(moki, hulk, TimeNow()), NewTextContent(text))
+
+	rMsg, missed := pack(t, store, iMsg, nil)
+	if len(missed) > 0 {
+		t.Errorf("missed: %v", missed)
+	}
+	// serialize & parse again
+	rMsg = NewReliableMessageWithMap(CopyMap(rMsg.Map()))
+	msg, err := unpack(store, rMsg, hulk)
+	if err != nil {
+		t.Fatal(err)
+	} else if body, ok := msg.Content().(TextContent); !ok || body.Text() != text {
+		t.Errorf("content error: %v", msg.Content())
+	} else if !msg.Sender().Equal(moki) || !msg.Receiver().Equal(hulk) {
+		t.Errorf("envelope error: %v -> %v", msg.Sender(), msg.Receiver())
+	}
+}
+
+func TestPackerGroupMissedMembers(t *testing.T) {
+	// no key for frank
+	store := newKeyStore(moki, hulk)
+	iMsg := NewInstantMessage(NewEnvelope(moki, group, TimeNow()), NewTextContent("Hi, all"))
+
+	rMsg, missed := pack(t, store, iMsg, []ID{moki, hulk, frank})
+	if len(missed) != 1 || !missed[0].Equal(frank) {
+		t.Fatalf("missed members error: %v", missed)
+	}
+	if _, err := unpack(store, rMsg, hulk); err != nil {
+		t.Errorf("member with key should decrypt: %v", err)
+	}
+	_, err := unpack(store, rMsg, frank)
+	if !errors.Is(err, ErrKeyMissing) {
+		t.Errorf("member without key should fail with ErrKeyMissing: %v", err)
+	}
+
+	// no key for any member
+	password := testutil.NewXORKey([]byte("0123456789abcdef"))
+	sMsg, missed := NewInstantMessagePacker(store).Encrypt(iMsg, password, []ID{frank})
+	if sMsg != nil || len(missed) != 1 {
+		t.Errorf("should fail when no member has key: %v, %v", sMsg, missed)
+	}
+}
+
+func TestPackerRejected(t *testing.T) {
+	store := newKeyStore(moki, hulk)
+	iMsg := NewInstantMessage(NewEnvelope(moki, hulk, TimeNow()), NewTextContent("secret"))
+	rMsg, _ := pack(t, store, iMsg, nil)
+
+	// tampered data
+	info := CopyMap(rMsg.Map())
+	info["data"] = "AAAA" + ConvertString(info["data"], "")
+	if _, err := unpack(store, NewReliableMessageWithMap(info), hulk); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered data should fail with ErrBadSignature: %v", err)
+	}
+
+	// signed by others
+	other := newKeyStore(moki, hulk)
+	other.privateKeys[moki.String()], _ = testutil.NewKeyPair("mallory")
+	forged, _ := pack(t, other, iMsg, nil)
+	if _, err := unpack(store, forged, hulk); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("forged signature should fail with ErrBadSignature: %v", err)
+	}
+
+	// decrypted by wrong key
+	store.privateKeys[hulk.String()], _ = testutil.NewKeyPair("mallory")
+	if _, err := unpack(store, rMsg, hulk); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("wrong private key should fail with ErrDecryptFailed: %v", err)
+	}
+}
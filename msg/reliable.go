@@ -55,7 +55,14 @@ import (
 //	    },
 //
 //	    // Digital signature for authenticity
-//	    "signature": "..."   // base64_encode(asymmetric_sign(data))
+//	    "signature": "...",  // base64_encode(asymmetric_sign(data))
+//
+//	    // Sender's meta & visa (optional, for first contact)
+//	    "meta"     : {...},
+//	    "visa"     : {...},
+//
+//	    // Relay records (optional, not signed by sender)
+//	    "traces"   : [...]   // RelayTrace list
 //	}
 type NetworkMessage struct {
 	//ReliableMessage
@@ -32,7 +32,10 @@ package dkd
 
 import (
 	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/mkm"
+	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
 )
 
@@ -54,7 +57,9 @@ import (
 //	    "keys"     : {
 //	        "{ID}"   : "...",  // base64_encode(asymmetric_encrypt(pwd))
 //	        "digest" : "..."   // hash(pwd.data)
-//	    }
+//	    },
+//	    // or just a single key for the receiver
+//	    "key"      : "..."     // base64_encode(asymmetric_encrypt(pwd))
 //	}
 type EncryptedMessage struct {
 	//SecureMessage
@@ -102,8 +107,63 @@ func (msg *EncryptedMessage) EncryptedKeys() StringKeyMap {
 	if dict, ok := keys.(StringKeyMap); ok {
 		return dict
 	}
-	// TODO: get from 'key'
-	return nil
+	// get from 'key'
+	key := msg.Get("key")
+	if key == nil {
+		// reused key
+		return nil
+	}
+	receiver := msg.Receiver()
+	return StringKeyMap{
+		receiver.String(): key,
+	}
+}
+
+// EncryptedKey returns the encrypted key for the receiver
+//
+// Searching order:
+//  1. keys["{name}@{address}/{terminal}"]
+//  2. keys["{name}@{address}"]
+//  3. key (if the receiver is the message receiver)
+//
+// Returns: encrypted key data (nil if not found, maybe reused)
+func (msg *EncryptedMessage) EncryptedKey(receiver ID) TransportableData {
+	var encodedKey any
+	keys := msg.Get("keys")
+	if dict, ok := keys.(StringKeyMap); ok {
+		// check for receiver with terminal
+		encodedKey = dict[receiver.String()]
+		if encodedKey == nil && receiver.Terminal() != "" {
+			// check for receiver without terminal
+			did := IDConcat(receiver.Name(), receiver.Address(), "")
+			encodedKey = dict[did]
+		}
+	}
+	if encodedKey == nil && isSameUser(msg.Receiver(), receiver) {
+		// check for single key
+		encodedKey = msg.Get("key")
+	}
+	if encodedKey == nil {
+		return nil
+	}
+	return ParseTransportableData(encodedKey)
+}
+
+// KeyDigest returns the digest of the symmetric key,
+// which is used to encrypt the message data
+func (msg *EncryptedMessage) KeyDigest() string {
+	keys := msg.Get("keys")
+	if dict, ok := keys.(StringKeyMap); ok {
+		return ConvertString(dict["digest"], "")
+	}
+	return ""
+}
+
+func isSameUser(a, b ID) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.Name() == b.Name() && a.Address().String() == b.Address().String()
 }
 
 // Override
@@ -116,3 +176,131 @@ func (msg *EncryptedMessage) Map() StringKeyMap {
 	// OK
 	return msg.BaseMessage.Map()
 }
+
+/*
+ *  Split/Trim group message
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~
+ *  for each member, get key from 'keys' and replace 'receiver' to member ID,
+ *  the group ID will be saved as 'group'.
+ */
+
+// Split separates a group message to messages for each member
+//
+// Members without an encrypted key in 'keys' will be skipped and returned
+// as 'missed', the caller should re-encrypt the message for them later;
+// if the message has no receiver key in 'keys' (reused key or broadcast message),
+// no member will be skipped.
+//
+// Parameters:
+//   - members : group members
+//
+// Returns: secure messages (reliable messages if signature exists),
+// and the members whose keys are missing;
+// nil if the receiver is not a group ID
+func (msg *EncryptedMessage) Split(members []ID) (messages []SecureMessage, missed []ID) {
+	receiver := msg.Receiver()
+	if receiver == nil || !receiver.IsGroup() {
+		//panic("receiver is not a group: " + receiver.String())
+		return nil, nil
+	}
+	info := msg.CopyMap(false)
+	// 1. move the receiver(group ID) to 'group'
+	//    this will help the receiver knows the group ID
+	//    when the group message separated to multi-messages;
+	//    if don't want the others know your membership,
+	//    DON'T do this.
+	group := msg.Group()
+	if group == nil {
+		group = receiver
+	}
+	info["group"] = group.String()
+	// 2. pack message for each member
+	hasKeys := msg.hasReceiverKeys()
+	messages = make([]SecureMessage, 0, len(members))
+	var item StringKeyMap
+	var key TransportableData
+	for _, member := range members {
+		key = msg.EncryptedKey(member)
+		if key == nil && hasKeys {
+			// public key for encryption not found when packing
+			missed = append(missed, member)
+			continue
+		}
+		item = CopyMap(info)
+		// change 'receiver' to each group member
+		item["receiver"] = member.String()
+		// keep encrypted key for this member
+		msg.trimKeys(item, member, key)
+		// repack message
+		messages = append(messages, newSecureMessage(item))
+	}
+	return messages, missed
+}
+
+// Trim picks out the message for one member
+//
+// Parameters:
+//   - member : group member
+//
+// Returns: secure message (reliable message if signature exists);
+// nil if it's not a group message, or the key for this member is missing
+func (msg *EncryptedMessage) Trim(member ID) SecureMessage {
+	info := msg.CopyMap(false)
+	// check 'group'
+	group := msg.Group()
+	if group == nil {
+		// if 'group' not exists, the 'receiver' must be a group ID here, and
+		// it will not be equal to the member of course,
+		// so move 'receiver' to 'group'
+		receiver := msg.Receiver()
+		if receiver == nil || !receiver.IsGroup() {
+			//panic("receiver is not a group: " + receiver.String())
+			return nil
+		}
+		info["group"] = receiver.String()
+	}
+	// fetch encrypted key for this member
+	key := msg.EncryptedKey(member)
+	if key == nil && msg.hasReceiverKeys() {
+		//panic("encrypted key not found for member: " + member.String())
+		return nil
+	}
+	msg.trimKeys(info, member, key)
+	info["receiver"] = member.String()
+	// repack message
+	return newSecureMessage(info)
+}
+
+// hasReceiverKeys checks whether any receiver key exists ("digest" is not a key)
+func (msg *EncryptedMessage) hasReceiverKeys() bool {
+	for name := range msg.EncryptedKeys() {
+		if name != "digest" {
+			return true
+		}
+	}
+	return false
+}
+
+// trimKeys keeps the encrypted key for the member, and the key digest
+func (msg *EncryptedMessage) trimKeys(info StringKeyMap, member ID, key TransportableData) {
+	delete(info, "keys")
+	delete(info, "key")
+	trimmed := NewMap()
+	if key != nil {
+		trimmed[member.String()] = key.Serialize()
+	}
+	digest := msg.KeyDigest()
+	if digest != "" {
+		trimmed["digest"] = digest
+	}
+	if len(trimmed) > 0 {
+		info["keys"] = trimmed
+	}
+}
+
+func newSecureMessage(info StringKeyMap) SecureMessage {
+	if _, exists := info["signature"]; exists {
+		return NewNetworkMessage(info, nil, nil)
+	}
+	return NewEncryptedMessage(info, nil)
+}
@@ -0,0 +1,151 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"testing"
+
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/mkm"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+func newGroupMessage(receiver ID, keys StringKeyMap) *EncryptedMessage {
+	info := StringKeyMap{
+		"sender":    moki.String(),
+		"receiver":  receiver.String(),
+		"time":      1700000000,
+		"data":      "AQIDBAUGBwg=",
+		"signature": "ERITFBUWFxg=",
+	}
+	if keys != nil {
+		info["keys"] = keys
+	}
+	return NewEncryptedMessage(info, nil)
+}
+
+func checkTrimmed(t *testing.T, sMsg SecureMessage, member ID, expected StringKeyMap) {
+	t.Helper()
+	if _, ok := sMsg.(ReliableMessage); !ok {
+		t.Errorf("signature lost: %v", sMsg.Map())
+	}
+	if !sMsg.Receiver().Equal(member) {
+		t.Errorf("receiver error: %v, expected: %v", sMsg.Receiver(), member)
+	} else if sMsg.GetString("group", "") != group.String() {
+		t.Errorf("group error: %v", sMsg.Get("group"))
+	}
+	keys, _ := sMsg.Get("keys").(StringKeyMap)
+	if len(keys) != len(expected) {
+		t.Errorf("keys error: %v, expected: %v", keys, expected)
+	}
+	for name, value := range expected {
+		if keys[name] != value {
+			t.Errorf("key for %s error: %v, expected: %v", name, keys[name], value)
+		}
+	}
+}
+
+func TestSplitGroupMessage(t *testing.T) {
+	sMsg := newGroupMessage(group, StringKeyMap{
+		moki.String(): "CQoLDA==",
+		hulk.String(): "DQ4PEA==",
+		"digest":      "abcd",
+	})
+	messages, missed := sMsg.Split([]ID{moki, hulk, frank})
+	if len(missed) != 1 || !missed[0].Equal(frank) {
+		t.Errorf("missed error: %v", missed)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("split error: %d", len(messages))
+	}
+	checkTrimmed(t, messages[0], moki, StringKeyMap{moki.String(): "CQoLDA==", "digest": "abcd"})
+	checkTrimmed(t, messages[1], hulk, StringKeyMap{hulk.String(): "DQ4PEA==", "digest": "abcd"})
+}
+
+func TestSplitDigestOnly(t *testing.T) {
+	// reused key, only digest
+	sMsg := newGroupMessage(group, StringKeyMap{
+		"digest": "abcd",
+	})
+	messages, missed := sMsg.Split([]ID{moki, hulk, frank})
+	if len(missed) != 0 || len(messages) != 3 {
+		t.Fatalf("split error: %d, missed: %v", len(messages), missed)
+	}
+	for i, member := range []ID{moki, hulk, frank} {
+		checkTrimmed(t, messages[i], member, StringKeyMap{"digest": "abcd"})
+	}
+	// no keys at all
+	sMsg = newGroupMessage(group, nil)
+	messages, missed = sMsg.Split([]ID{moki, hulk})
+	if len(missed) != 0 || len(messages) != 2 {
+		t.Fatalf("split error: %d, missed: %v", len(messages), missed)
+	}
+	checkTrimmed(t, messages[1], hulk, nil)
+}
+
+func TestSplitBroadcastMessage(t *testing.T) {
+	sMsg := newGroupMessage(EVERYONE, nil)
+	messages, missed := sMsg.Split([]ID{moki, hulk})
+	if len(missed) != 0 || len(messages) != 2 {
+		t.Fatalf("split error: %d, missed: %v", len(messages), missed)
+	} else if messages[0].GetString("group", "") != EVERYONE.String() {
+		t.Errorf("group error: %v", messages[0].Get("group"))
+	}
+	// not a group
+	sMsg = newGroupMessage(hulk, nil)
+	if messages, _ = sMsg.Split([]ID{moki, hulk}); messages != nil {
+		t.Errorf("personal message should not be split: %v", messages)
+	}
+}
+
+func TestTrimGroupMessage(t *testing.T) {
+	sMsg := newGroupMessage(group, StringKeyMap{
+		moki.String(): "CQoLDA==",
+		hulk.String(): "DQ4PEA==",
+		"digest":      "abcd",
+	})
+	checkTrimmed(t, sMsg.Trim(hulk), hulk, StringKeyMap{hulk.String(): "DQ4PEA==", "digest": "abcd"})
+	if msg := sMsg.Trim(frank); msg != nil {
+		t.Errorf("member without key should not be trimmed: %v", msg.Map())
+	}
+	// reused key, only digest
+	sMsg = newGroupMessage(group, StringKeyMap{
+		"digest": "abcd",
+	})
+	checkTrimmed(t, sMsg.Trim(frank), frank, StringKeyMap{"digest": "abcd"})
+	// not a group
+	sMsg = newGroupMessage(hulk, nil)
+	if msg := sMsg.Trim(hulk); msg != nil {
+		t.Errorf("personal message should not be trimmed: %v", msg.Map())
+	}
+}
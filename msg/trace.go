@@ -0,0 +1,180 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// RelayTrace records a station which the message passed through
+//
+// The traces are stored out of 'data', so appending them will not
+// invalidate the sender's signature.
+//
+//	data format: {
+//	    "ID"        : "station@xxx",
+//	    "time"      : 123,
+//	    "signature" : "..."  // base64_encode(station.sign(trace_data)), optional
+//	}
+//
+//	trace_data = canonical_json({
+//	    "ID"        : "station@xxx",
+//	    "time"      : 123,
+//	    "signature" : "..."  // the sender's signature of the message
+//	})
+type RelayTrace struct {
+	*Dictionary
+}
+
+func NewRelayTrace(dict StringKeyMap, station ID, when Time) *RelayTrace {
+	if dict == nil {
+		if TimeIsNil(when) {
+			when = TimeNow()
+		}
+		dict = StringKeyMap{}
+		dict["ID"] = station.String()
+		dict["time"] = TimeToFloat64(when)
+	}
+	return &RelayTrace{
+		Dictionary: NewDictionary(dict),
+	}
+}
+
+// ParseRelayTrace parses a trace item, it can be a map or a station ID string
+func ParseRelayTrace(trace any) *RelayTrace {
+	if trace == nil {
+		return nil
+	} else if t, ok := trace.(*RelayTrace); ok {
+		return t
+	} else if str, ok := trace.(string); ok {
+		// old version: station ID only
+		station := ParseID(str)
+		if station == nil {
+			return nil
+		}
+		return NewRelayTrace(StringKeyMap{"ID": str}, station, nil)
+	}
+	info := FetchMap(trace)
+	if info == nil || info["ID"] == nil {
+		return nil
+	}
+	return NewRelayTrace(info, nil, nil)
+}
+
+func (trace *RelayTrace) Station() ID {
+	return ParseID(trace.Get("ID"))
+}
+
+func (trace *RelayTrace) Time() Time {
+	return trace.GetTime("time", nil)
+}
+
+func (trace *RelayTrace) Signature() TransportableData {
+	return ParseTransportableData(trace.Get("signature"))
+}
+
+// SignData builds the data to be signed by the station
+func (trace *RelayTrace) SignData(msgSignature TransportableData) []byte {
+	info := StringKeyMap{
+		"ID":   trace.Get("ID"),
+		"time": trace.Get("time"),
+	}
+	if msgSignature != nil {
+		info["signature"] = msgSignature.Serialize()
+	}
+	return UTF8Encode(CanonicalJSONEncodeMap(info))
+}
+
+//
+//  Traces of Network Message
+//
+
+// Traces returns the stations which the message passed through
+func (msg *NetworkMessage) Traces() []*RelayTrace {
+	array := FetchList(msg.Get("traces"))
+	traces := make([]*RelayTrace, 0, len(array))
+	for _, item := range array {
+		trace := ParseRelayTrace(item)
+		if trace != nil {
+			traces = append(traces, trace)
+		}
+	}
+	return traces
+}
+
+// IsRelayedBy checks whether the station has relayed this message before
+func (msg *NetworkMessage) IsRelayedBy(station ID) bool {
+	for _, trace := range msg.Traces() {
+		if station.Equal(trace.Station()) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTrace appends a trace record of the station
+//
+// Parameters:
+//   - station : current station ID
+//   - signKey : private key of the station (nil means not signed)
+//
+// Returns: ErrRelayLoop if the station relayed this message before
+func (msg *NetworkMessage) AddTrace(station ID, signKey SignKey) (*RelayTrace, error) {
+	if msg.IsRelayedBy(station) {
+		return nil, NewUnpackError(ErrRelayLoop, "already relayed by "+station.String(), msg)
+	}
+	trace := NewRelayTrace(nil, station, nil)
+	if signKey != nil {
+		signature := signKey.Sign(trace.SignData(msg.Signature()))
+		trace.Set("signature", NewBase64DataWithBytes(signature).Serialize())
+	}
+	array := FetchList(msg.Get("traces"))
+	traces := make([]any, 0, len(array)+1)
+	traces = append(traces, array...)
+	traces = append(traces, trace.Map())
+	msg.Set("traces", traces)
+	return trace, nil
+}
+
+// VerifyTrace checks the trace signature with the station's public key
+func (msg *NetworkMessage) VerifyTrace(trace *RelayTrace, publicKey VerifyKey) bool {
+	signature := trace.Signature()
+	if signature == nil {
+		// not signed
+		return false
+	}
+	data := trace.SignData(msg.Signature())
+	return publicKey.Verify(data, signature.Bytes())
+}
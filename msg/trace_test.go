@@ -0,0 +1,143 @@
+/* license: https://mit-license.org
+ *
+ *  Dao-Ke-Dao: Universal Message Module
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dimchat/core-go/internal/testutil"
+
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+var (
+	station1 = testutil.IDFromString("station1@2PpB6iscuBjA15oTjAsiswoX9qis5V3c1Dq")
+	station2 = testutil.IDFromString("station2@2PkzQpe8ydmX1BPdmtAS9ejn6hdbASxSQa8")
+)
+
+func newRelayMessage(t *testing.T, text string) *NetworkMessage {
+	store := newKeyStore(moki, hulk)
+	iMsg := NewInstantMessage(NewEnvelope(moki, hulk, TimeNow()), NewTextContent(text))
+	rMsg, _ := pack(t, store, iMsg, nil)
+	return NewNetworkMessage(CopyMap(rMsg.Map()), nil, nil)
+}
+
+func TestRelayTrace(t *testing.T) {
+	sKey1, pKey1 := testutil.NewKeyPair(station1.String())
+	sKey2, pKey2 := testutil.NewKeyPair(station2.String())
+	rMsg := newRelayMessage(t, "Hello, world!")
+
+	if _, err := rMsg.AddTrace(station1, sKey1); err != nil {
+		t.Fatal(err)
+	} else if _, err = rMsg.AddTrace(station2, sKey2); err != nil {
+		t.Fatal(err)
+	}
+
+	// serialize & parse again
+	rMsg = NewNetworkMessage(CopyMap(rMsg.Map()), nil, nil)
+	traces := rMsg.Traces()
+	if len(traces) != 2 {
+		t.Fatalf("traces error: %v", rMsg.Get("traces"))
+	} else if !station1.Equal(traces[0].Station()) || !station2.Equal(traces[1].Station()) {
+		t.Errorf("trace stations error: %v, %v", traces[0].Station(), traces[1].Station())
+	} else if !rMsg.VerifyTrace(traces[0], pKey1) || !rMsg.VerifyTrace(traces[1], pKey2) {
+		t.Errorf("failed to verify traces")
+	}
+
+	// signed by the wrong station
+	if rMsg.VerifyTrace(traces[0], pKey2) {
+		t.Errorf("trace should not be verified by another station's key")
+	}
+
+	// trace copied to another message
+	other := newRelayMessage(t, "Hi!")
+	if other.VerifyTrace(traces[0], pKey1) {
+		t.Errorf("trace should be bound to the message signature")
+	}
+}
+
+func TestRelayTraceTampered(t *testing.T) {
+	sKey1, pKey1 := testutil.NewKeyPair(station1.String())
+	rMsg := newRelayMessage(t, "Hello, world!")
+	trace, err := rMsg.AddTrace(station1, sKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := NewRelayTrace(CopyMap(trace.Map()), nil, nil)
+	forged.Set("time", TimeToFloat64(trace.Time())+60)
+	if rMsg.VerifyTrace(forged, pKey1) {
+		t.Errorf("trace with modified time should not be verified")
+	}
+	forged = NewRelayTrace(CopyMap(trace.Map()), nil, nil)
+	forged.Set("ID", station2.String())
+	if rMsg.VerifyTrace(forged, pKey1) {
+		t.Errorf("trace with modified station should not be verified")
+	}
+
+	// not signed
+	if _, err = rMsg.AddTrace(station2, nil); err != nil {
+		t.Fatal(err)
+	}
+	traces := rMsg.Traces()
+	if rMsg.VerifyTrace(traces[len(traces)-1], pKey1) {
+		t.Errorf("unsigned trace should not be verified")
+	}
+}
+
+func TestRelayLoop(t *testing.T) {
+	sKey1, _ := testutil.NewKeyPair(station1.String())
+	rMsg := newRelayMessage(t, "Hello, world!")
+	if _, err := rMsg.AddTrace(station1, sKey1); err != nil {
+		t.Fatal(err)
+	} else if _, err = rMsg.AddTrace(station2, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	trace, err := rMsg.AddTrace(station1, sKey1)
+	if !errors.Is(err, ErrRelayLoop) || trace != nil {
+		t.Errorf("relay loop should be detected: %v", err)
+	} else if len(rMsg.Traces()) != 2 {
+		t.Errorf("traces should not be changed: %v", rMsg.Get("traces"))
+	}
+
+	// old version: station ID string only
+	rMsg = newRelayMessage(t, "Hello, world!")
+	rMsg.Set("traces", []any{station1.String()})
+	if !rMsg.IsRelayedBy(station1) || rMsg.IsRelayedBy(station2) {
+		t.Errorf("old traces error: %v", rMsg.Get("traces"))
+	} else if _, err = rMsg.AddTrace(station1, nil); !errors.Is(err, ErrRelayLoop) {
+		t.Errorf("relay loop should be detected with old traces: %v", err)
+	}
+}
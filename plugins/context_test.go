@@ -0,0 +1,216 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package plugins_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/ext"
+	. "github.com/dimchat/core-go/format"
+	"github.com/dimchat/core-go/internal/testutil"
+	. "github.com/dimchat/core-go/plugins"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// tagCoder marks the decoded data, so we can tell which coder was used
+type tagCoder struct{ tag string }
+
+func (coder tagCoder) Encode(data []byte) string { return coder.tag + string(data) }
+func (coder tagCoder) Decode(text string) []byte { return []byte(coder.tag + text) }
+
+type pingCommand struct {
+	Command
+}
+
+func newContexts() (*Context, *Context) {
+	loader := &ExtensionLoader{}
+
+	fmtA := NewFormatContext()
+	fmtA.SetDataCoder(BASE_64, tagCoder{tag: "A:"})
+	ctxA := NewContext(fmtA)
+	loader.LoadInto(ctxA)
+
+	fmtB := NewFormatContext()
+	fmtB.SetDataCoder(BASE_64, tagCoder{tag: "B:"})
+	ctxB := NewContext(fmtB)
+	loader.LoadInto(ctxB)
+	ctxB.SetCommandFactory("ping", NewCommandParser(func(dict StringKeyMap) Command {
+		return &pingCommand{NewCommandWithMap(dict)}
+	}))
+	return ctxA, ctxB
+}
+
+func TestContextCommandFactories(t *testing.T) {
+	ctxA, ctxB := newContexts()
+	info := StringKeyMap{
+		"type":    ContentType.COMMAND,
+		"sn":      1234,
+		"command": "ping",
+	}
+
+	// ctxA does not know 'ping'
+	cmd := ctxA.ParseCommand(info)
+	if cmd == nil || cmd.CMD() != "ping" {
+		t.Fatalf("command error: %v", cmd)
+	} else if _, ok := cmd.(*pingCommand); ok {
+		t.Errorf("ctxA should not parse 'ping' with the factory of ctxB")
+	}
+
+	// ctxB knows it, both as command & as content
+	cmd = ctxB.ParseCommand(info)
+	if _, ok := cmd.(*pingCommand); !ok {
+		t.Errorf("ctxB should parse 'ping': %v", cmd)
+	}
+	content := ctxB.ParseContent(info)
+	if _, ok := content.(*pingCommand); !ok {
+		t.Errorf("ctxB should parse 'ping' content: %v", content)
+	}
+
+	// neither leaks into the default context
+	if DefaultContext().GetCommandFactory("ping") != nil {
+		t.Errorf("'ping' should not be registered globally")
+	}
+
+	// legacy 'cmd' field
+	cmd = ctxB.ParseCommand(StringKeyMap{
+		"type": ContentType.COMMAND,
+		"sn":   5678,
+		"cmd":  "ping",
+	})
+	if _, ok := cmd.(*pingCommand); !ok {
+		t.Errorf("ctxB should parse legacy 'cmd' field: %v", cmd)
+	}
+}
+
+// actionHelper reads the command name from "action"
+type actionHelper struct{}
+
+func (actionHelper) GetCMD(content StringKeyMap, defaultValue string) string {
+	return ConvertString(content["action"], defaultValue)
+}
+
+func TestGeneralCommandHelper(t *testing.T) {
+	info := StringKeyMap{
+		"type":    ContentType.COMMAND,
+		"sn":      1234,
+		"command": "ping",
+		"action":  "pong",
+	}
+	cmd := NewCommandWithMap(info)
+	if cmd.CMD() != "ping" {
+		t.Errorf("command name error: %s", cmd.CMD())
+	}
+	SetGeneralCommandHelper(actionHelper{})
+	defer SetGeneralCommandHelper(nil)
+	if cmd.CMD() != "pong" {
+		t.Errorf("general command helper not used: %s", cmd.CMD())
+	}
+}
+
+func TestContextDataCoders(t *testing.T) {
+	SetBase64Coder(testutil.Base64Coder{})
+	ctxA, ctxB := newContexts()
+	info := StringKeyMap{
+		"type":     ContentType.IMAGE,
+		"sn":       1234,
+		"filename": "a.png",
+		"data":     "data:image/png;base64,xyz",
+	}
+
+	check := func(ctx *Context, expected string) {
+		content := ctx.ParseContent(info)
+		file, ok := content.(FileContent)
+		if !ok {
+			t.Fatalf("file content error: %v", content)
+		}
+		data := file.Data()
+		if data == nil {
+			t.Fatalf("file data not found: %v", file)
+		} else if !bytes.Equal(data.Bytes(), []byte(expected)) {
+			t.Errorf("data error: %q, expected: %q", data.Bytes(), expected)
+		}
+	}
+	check(ctxA, "A:xyz")
+	check(ctxB, "B:xyz")
+
+	// PNF parsed by the format context
+	pnf := ctxB.ParsePortableNetworkFile("data:image/png;base64,abc")
+	if pnf == nil || pnf.Data() == nil {
+		t.Fatalf("PNF error: %v", pnf)
+	} else if string(pnf.Data().Bytes()) != "B:abc" {
+		t.Errorf("PNF data error: %q", pnf.Data().Bytes())
+	}
+}
+
+func TestContextPlainData(t *testing.T) {
+	_, ctxB := newContexts()
+	info := StringKeyMap{
+		"type":     ContentType.FILE,
+		"sn":       1234,
+		"filename": "a.txt",
+		"data":     "xyz",
+	}
+	// plain base64 string
+	file, ok := ctxB.ParseContent(info).(FileContent)
+	if !ok || file.Data() == nil {
+		t.Fatalf("file content error: %v", file)
+	} else if string(file.Data().Bytes()) != "B:xyz" {
+		t.Errorf("data error: %q", file.Data().Bytes())
+	}
+
+	// inner contents of array
+	array, ok := ctxB.ParseContent(StringKeyMap{
+		"type":     ContentType.ARRAY,
+		"sn":       5678,
+		"contents": []any{info},
+	}).(ArrayContent)
+	if !ok || len(array.Contents()) != 1 {
+		t.Fatalf("array content error: %v", array)
+	}
+	file, ok = array.Contents()[0].(FileContent)
+	if !ok || file.Data() == nil {
+		t.Fatalf("inner content error: %v", array.Contents()[0])
+	} else if string(file.Data().Bytes()) != "B:xyz" {
+		t.Errorf("inner data error: %q", file.Data().Bytes())
+	}
+}
+
+func TestSetNilDefaultContext(t *testing.T) {
+	ctx := DefaultContext()
+	SetDefaultContext(nil)
+	if DefaultContext() != ctx {
+		t.Errorf("nil context should be ignored")
+	}
+}
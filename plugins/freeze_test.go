@@ -0,0 +1,151 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package plugins_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/core-go/plugins"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// register coders & factories while parsing on other goroutines,
+// run with '-race'
+func TestConcurrentRegistration(t *testing.T) {
+	ctx, _ := newContexts()
+	helper := ctx.GetContentHelper()
+	text := StringKeyMap{
+		"type": ContentType.TEXT,
+		"sn":   1,
+		"text": "Hello",
+	}
+	image := StringKeyMap{
+		"type":     ContentType.IMAGE,
+		"sn":       2,
+		"filename": "a.png",
+		"data":     "data:image/png;base64,xyz",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("cmd-%d-%d", i, j)
+				ctx.SetDataCoder(BASE_64, tagCoder{tag: "A:"})
+				ctx.SetCommandFactory(name, NewCommandParser(NewCommandWithMap))
+				helper.SetContentFactory(ContentType.TEXT, NewContentParser(NewTextContentWithMap))
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("cmd-%d-%d", i, j)
+				cmd := ctx.ParseCommand(StringKeyMap{
+					"type":    ContentType.COMMAND,
+					"sn":      j,
+					"command": name,
+				})
+				if cmd == nil || cmd.CMD() != name {
+					t.Errorf("command error: %v", cmd)
+				}
+				if ctx.ParseContent(text) == nil {
+					t.Errorf("text content error")
+				}
+				file, ok := ctx.ParseContent(image).(FileContent)
+				if !ok || file.Data() == nil {
+					t.Errorf("file content error: %v", file)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+type nullCoder struct{}
+
+func (nullCoder) Encode([]byte) string { return "" }
+func (nullCoder) Decode(string) []byte { return nil }
+
+// registration after Freeze() is ignored, not panic
+func TestFreezeThenRegister(t *testing.T) {
+	ctx, _ := newContexts()
+	ctx.Freeze()
+	if !ctx.IsFrozen() || !ctx.FormatContext.IsFrozen() {
+		t.Fatalf("context should be frozen")
+	}
+	helper := ctx.GetContentHelper()
+	commandHelper := ctx.GetCommandHelper()
+	quoteHelper := ctx.GetQuoteHelper()
+
+	if ctx.SetDataCoder(BASE_64, nullCoder{}) ||
+		ctx.SetTransportableFileWrapperFactory(nil) ||
+		ctx.SetCommandHelper(NewCommandGeneralFactory()) ||
+		ctx.SetContentHelper(NewMessageGeneralFactory()) ||
+		ctx.SetQuoteHelper(nil) ||
+		ctx.CustomizedRegistry().SetPayloadDecoder("chat.dim.test", "*", nil) {
+		t.Errorf("setters should return false after frozen")
+	}
+	ctx.SetCommandFactory("ping", NewCommandParser(NewCommandWithMap))
+	helper.SetContentFactory(ContentType.TEXT, nil)
+
+	if _, ok := ctx.GetDataCoder(BASE_64).(nullCoder); ok {
+		t.Errorf("data coder should not be replaced")
+	} else if ctx.GetTransportableFileWrapperFactory() == nil {
+		t.Errorf("wrapper factory should not be replaced")
+	} else if ctx.GetCommandFactory("ping") != nil {
+		t.Errorf("command factory should not be set")
+	} else if ctx.GetCommandHelper() != commandHelper {
+		t.Errorf("command helper should not be replaced")
+	} else if ctx.GetContentHelper() != helper {
+		t.Errorf("content helper should not be replaced")
+	} else if ctx.GetQuoteHelper() != quoteHelper {
+		t.Errorf("quote helper should not be replaced")
+	} else if helper.GetContentFactory(ContentType.TEXT) == nil {
+		t.Errorf("content factory should not be removed")
+	}
+
+	// still works
+	content := ctx.ParseContent(StringKeyMap{
+		"type": ContentType.TEXT,
+		"sn":   1,
+		"text": "Hello",
+	})
+	if text, ok := content.(TextContent); !ok || text.Text() != "Hello" {
+		t.Errorf("text content error: %v", content)
+	}
+}
@@ -0,0 +1,276 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package plugins
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/dimchat/core-go/crypto"
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/msg"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/ext"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+)
+
+/**
+ *  Extension Loader
+ */
+
+// ExtensionLoader registers the helpers and all built-in factories
+//
+// Usage:
+//
+//	// ... load crypto plugins
+//	loader := &ExtensionLoader{}
+//	if err := loader.Load(); err != nil {
+//	    // crypto plugins not loaded
+//	}
+//	// ... register other factories
+//	loader.Freeze()
+type ExtensionLoader struct {
+	once sync.Once
+
+	// the PLAIN key factory needs the symmetric key helper from crypto plugins,
+	// so it's registered separately, and will be retried until succeeded
+	cryptoMutex  sync.Mutex
+	cryptoLoaded bool
+}
+
+var ErrCryptoNotLoaded = errors.New("symmetric key helper not found, load crypto plugins first")
+
+// Load registers core helpers & factories (only once, safe for concurrent calls)
+//
+// Returns: ErrCryptoNotLoaded if the crypto plugins are not loaded yet,
+// call Load() again after they are loaded to register the PLAIN key
+// (the other helpers & factories will not be registered twice)
+func (loader *ExtensionLoader) Load() error {
+	loader.once.Do(func() {
+		// try to load all extensions
+		loader.RegisterCoreHelpers()
+		loader.RegisterMessageFactories()
+		loader.RegisterContentFactories()
+		loader.RegisterCommandFactories()
+	})
+	return loader.loadCrypto()
+}
+
+func (loader *ExtensionLoader) loadCrypto() error {
+	loader.cryptoMutex.Lock()
+	defer loader.cryptoMutex.Unlock()
+	if loader.cryptoLoaded {
+		return nil
+	}
+	err := loader.RegisterCryptoFactories()
+	if err != nil {
+		return err
+	}
+	loader.cryptoLoaded = true
+	return nil
+}
+
+// LoadInto registers the built-in content & command factories into ctx,
+// so ctx.ParseContent() and ctx.ParseCommand() will parse with them
+// and the coders of ctx.
+//
+// The envelope & message factories are shared by the process,
+// call Load() for them; so the messages inside forward & combine
+// contents, and the envelope inside quote content, are parsed by them,
+// not with ctx.
+func (loader *ExtensionLoader) LoadInto(ctx *Context) {
+	factory := NewMessageGeneralFactory()
+	loader.registerContentFactories(factory.SetContentFactory, ctx)
+	ctx.SetContentHelper(factory)
+	loader.registerCommandFactories(ctx.SetCommandFactory)
+}
+
+// Freeze stops accepting registrations for the default context and factories,
+// so the registries could be read concurrently without any change
+func (loader *ExtensionLoader) Freeze() {
+	DefaultContext().Freeze()
+	if registry, ok := GetContentHelper().(interface{ Freeze() }); ok {
+		registry.Freeze()
+	}
+}
+
+// RegisterCoreHelpers installs the general factory for contents, envelope & messages
+func (loader *ExtensionLoader) RegisterCoreHelpers() {
+	factory := NewMessageGeneralFactory()
+	SetGeneralMessageHelper(factory)
+	SetContentHelper(factory)
+	SetEnvelopeHelper(factory)
+	SetInstantMessageHelper(factory)
+	SetSecureMessageHelper(factory)
+	SetReliableMessageHelper(factory)
+}
+
+// RegisterMessageFactories sets factories for envelope & messages
+func (loader *ExtensionLoader) RegisterMessageFactories() {
+	// Envelope factory
+	SetEnvelopeFactory(&MessageEnvelopeFactory{})
+	// Message factories
+	factory := NewMessageFactory()
+	SetInstantMessageFactory(factory)
+	SetSecureMessageFactory(factory)
+	SetReliableMessageFactory(factory)
+}
+
+// RegisterContentFactories sets factories for all core content types
+func (loader *ExtensionLoader) RegisterContentFactories() {
+	loader.registerContentFactories(SetContentFactory, nil)
+}
+
+// ctx is used for parsing commands & file contents (nil means the default context)
+func (loader *ExtensionLoader) registerContentFactories(set func(MessageType, ContentFactory), ctx *Context) {
+
+	// Text
+	set(ContentType.TEXT, NewContentParser(NewTextContentWithMap))
+
+	if ctx == nil {
+		// File
+		set(ContentType.FILE, NewContentParser(NewFileContentWithMap))
+		// Image
+		set(ContentType.IMAGE, NewContentParser(NewImageContentWithMap))
+		// Audio
+		set(ContentType.AUDIO, NewContentParser(NewAudioContentWithMap))
+		// Video
+		set(ContentType.VIDEO, NewContentParser(NewVideoContentWithMap))
+	} else {
+		// File, Image, Audio & Video with coders of the context
+		parser := NewContentParser(FileContentParserWithContext(ctx.FormatContext))
+		set(ContentType.FILE, parser)
+		set(ContentType.IMAGE, parser)
+		set(ContentType.AUDIO, parser)
+		set(ContentType.VIDEO, parser)
+	}
+
+	// Web Page
+	set(ContentType.PAGE, NewContentParser(NewPageContentWithMap))
+
+	// Name Card
+	set(ContentType.NAME_CARD, NewContentParser(NewNameCardWithMap))
+
+	// Quote
+	set(ContentType.QUOTE, NewContentParser(NewQuoteContentWithMap))
+
+	// Money
+	set(ContentType.MONEY, NewContentParser(NewMoneyContentWithMap))
+	set(ContentType.TRANSFER, NewContentParser(NewTransferContentWithMap))
+	set(ContentType.LUCK_MONEY, NewContentParser(NewLuckyMoneyContentWithMap))
+	set(ContentType.CLAIM_PAYMENT, NewContentParser(NewClaimPaymentContentWithMap))
+	set(ContentType.SPLIT_BILL, NewContentParser(NewSplitBillContentWithMap))
+	// ...
+
+	// Customized
+	set(ContentType.APPLICATION, NewContentParser(NewCustomizedContentWithMap))
+	set(ContentType.CUSTOMIZED, NewContentParser(NewCustomizedContentWithMap))
+
+	// Command
+	set(ContentType.COMMAND, NewGeneralCommandFactoryWithContext(ctx, NewCommandWithMap))
+
+	// History Command
+	set(ContentType.HISTORY, NewGeneralCommandFactoryWithContext(ctx, NewHistoryCommandWithMap))
+
+	// Content Array
+	if ctx == nil {
+		set(ContentType.ARRAY, NewContentParser(NewArrayContentWithMap))
+	} else {
+		set(ContentType.ARRAY, NewContentParser(ArrayContentParserWithContext(ctx)))
+	}
+
+	// Combine and Forward
+	// (the messages are parsed by the process-wide message factories,
+	// so are the envelopes in quote content, they don't use ctx)
+	set(ContentType.COMBINE_FORWARD, NewContentParser(NewCombineContentWithMap))
+
+	// Top-Secret
+	set(ContentType.FORWARD, NewContentParser(NewForwardContentWithMap))
+
+	// unknown content type
+	set(ContentType.ANY, NewContentParser(NewContentWithMap))
+}
+
+// RegisterCommandFactories sets factories for all core commands
+func (loader *ExtensionLoader) RegisterCommandFactories() {
+	loader.registerCommandFactories(SetCommandFactory)
+}
+
+func (loader *ExtensionLoader) registerCommandFactories(set func(string, CommandFactory)) {
+
+	// Meta Command
+	set(META, NewCommandParser(NewMetaCommandWithMap))
+
+	// Documents Command
+	set(DOCUMENTS, NewCommandParser(NewDocumentCommandWithMap))
+
+	// Receipt Command
+	set(RECEIPT, NewCommandParser(NewReceiptCommandWithMap))
+
+	// Handshake Command
+	set(HANDSHAKE, NewCommandParser(NewHandshakeCommandWithMap))
+
+	// Account Commands
+	set(REGISTER, NewCommandParser(NewRegisterCommandWithMap))
+	set(SUICIDE, NewCommandParser(NewSuicideCommandWithMap))
+
+	// Group Commands
+	set("group", NewCommandParser(NewGroupCommandWithMap))
+	set(INVITE, NewCommandParser(NewInviteCommandWithMap))
+	// 'expel' is deprecated (use 'reset' instead)
+	set(EXPEL, NewCommandParser(NewExpelCommandWithMap))
+	set(JOIN, NewCommandParser(NewJoinCommandWithMap))
+	set(QUIT, NewCommandParser(NewQuitCommandWithMap))
+	set(RESET, NewCommandParser(NewResetCommandWithMap))
+	// Group Administration
+	set(FOUND, NewCommandParser(NewFoundCommandWithMap))
+	set(ABDICATE, NewCommandParser(NewAbdicateCommandWithMap))
+	set(HIRE, NewCommandParser(NewHireCommandWithMap))
+	set(FIRE, NewCommandParser(NewFireCommandWithMap))
+	set(RESIGN, NewCommandParser(NewResignCommandWithMap))
+
+	// unknown command
+	set("*", NewCommandParser(NewCommandWithMap))
+}
+
+// RegisterCryptoFactories sets factory for PLAIN key (broadcast message)
+//
+// The symmetric key helper should be installed by the crypto plugins before.
+//
+// Returns: ErrCryptoNotLoaded if the symmetric key helper not found
+func (loader *ExtensionLoader) RegisterCryptoFactories() error {
+	if GetSymmetricKeyHelper() == nil {
+		return ErrCryptoNotLoaded
+	}
+	SetSymmetricKeyFactory(PLAIN, &PlainKeyFactory{})
+	return nil
+}
@@ -0,0 +1,71 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package plugins_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/dimchat/core-go/crypto"
+	"github.com/dimchat/core-go/internal/testutil"
+	. "github.com/dimchat/core-go/plugins"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+func TestLoaderWithoutCrypto(t *testing.T) {
+	SetSymmetricKeyHelper(nil)
+	defer SetSymmetricKeyHelper(nil)
+
+	loader := &ExtensionLoader{}
+	if err := loader.Load(); !errors.Is(err, ErrCryptoNotLoaded) {
+		t.Fatalf("should fail without crypto plugins: %v", err)
+	}
+	// core helpers are loaded anyway
+	if GetContentHelper() == nil || GetCommandFactory(HANDSHAKE) == nil {
+		t.Errorf("core factories not loaded")
+	}
+
+	// load again after crypto plugins installed
+	helper := testutil.NewStubKeyHelper()
+	SetSymmetricKeyHelper(helper)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	} else if helper.GetSymmetricKeyFactory(PLAIN) == nil {
+		t.Errorf("PLAIN key factory not registered")
+	}
+	key := ParseSymmetricKey(StringKeyMap{"algorithm": PLAIN})
+	if !IsPlainKey(key) {
+		t.Errorf("PLAIN key error: %v", key)
+	}
+}
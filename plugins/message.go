@@ -0,0 +1,318 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package plugins
+
+import (
+	"sync"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Message GeneralFactory
+ */
+
+// MessageGeneralFactory is the default implementation of the dkd helpers
+//
+// Keeps the content factories mapped by content type,
+// and the factories for envelope & messages.
+//
+// All factories are protected by a read-write lock;
+// after Freeze() is called, no more factory can be set.
+type MessageGeneralFactory struct {
+	//GeneralMessageHelper
+	//ContentHelper
+	//EnvelopeHelper
+	//InstantMessageHelper
+	//SecureMessageHelper
+	//ReliableMessageHelper
+
+	mutex  sync.RWMutex
+	frozen bool
+
+	contentFactories map[MessageType]ContentFactory
+
+	envelopeFactory EnvelopeFactory
+
+	instantMessageFactory  InstantMessageFactory
+	secureMessageFactory   SecureMessageFactory
+	reliableMessageFactory ReliableMessageFactory
+}
+
+func NewMessageGeneralFactory() *MessageGeneralFactory {
+	return &MessageGeneralFactory{
+		contentFactories: make(map[MessageType]ContentFactory, 32),
+	}
+}
+
+// Freeze stops accepting factories,
+// any further registration will be ignored (check it with IsFrozen())
+func (factory *MessageGeneralFactory) Freeze() {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	factory.frozen = true
+}
+
+func (factory *MessageGeneralFactory) IsFrozen() bool {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.frozen
+}
+
+//
+//  Message Type
+//
+
+// Override
+func (factory *MessageGeneralFactory) GetContentType(content StringKeyMap, defaultValue MessageType) MessageType {
+	return ConvertString(content["type"], defaultValue)
+}
+
+//
+//  Content
+//
+
+// Override
+func (factory *MessageGeneralFactory) SetContentFactory(msgType MessageType, f ContentFactory) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	if factory.frozen {
+		// frozen, ignore it
+		return
+	}
+	factory.contentFactories[msgType] = f
+}
+
+// Override
+func (factory *MessageGeneralFactory) GetContentFactory(msgType MessageType) ContentFactory {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.contentFactories[msgType]
+}
+
+// Override
+func (factory *MessageGeneralFactory) ParseContent(content any) Content {
+	if ValueIsNil(content) {
+		return nil
+	} else if body, ok := content.(Content); ok {
+		return body
+	}
+	info := FetchMap(content)
+	if info == nil {
+		//panic("content error")
+		return nil
+	}
+	// get factory by content type
+	msgType := factory.GetContentType(info, "")
+	f := factory.GetContentFactory(msgType)
+	if f == nil {
+		// unknown content type, get default content factory
+		f = factory.GetContentFactory(ContentType.ANY)
+		if f == nil {
+			//panic("default content factory not found")
+			return nil
+		}
+	}
+	return f.ParseContent(info)
+}
+
+//
+//  Envelope
+//
+
+// Override
+func (factory *MessageGeneralFactory) SetEnvelopeFactory(f EnvelopeFactory) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	if factory.frozen {
+		// frozen, ignore it
+		return
+	}
+	factory.envelopeFactory = f
+}
+
+// Override
+func (factory *MessageGeneralFactory) GetEnvelopeFactory() EnvelopeFactory {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.envelopeFactory
+}
+
+// Override
+func (factory *MessageGeneralFactory) CreateEnvelope(from, to ID, when Time) Envelope {
+	f := factory.GetEnvelopeFactory()
+	return f.CreateEnvelope(from, to, when)
+}
+
+// Override
+func (factory *MessageGeneralFactory) ParseEnvelope(env any) Envelope {
+	if ValueIsNil(env) {
+		return nil
+	} else if head, ok := env.(Envelope); ok {
+		return head
+	}
+	info := FetchMap(env)
+	if info == nil {
+		//panic("envelope error")
+		return nil
+	}
+	f := factory.GetEnvelopeFactory()
+	return f.ParseEnvelope(info)
+}
+
+//
+//  Instant Message
+//
+
+// Override
+func (factory *MessageGeneralFactory) SetInstantMessageFactory(f InstantMessageFactory) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	if factory.frozen {
+		// frozen, ignore it
+		return
+	}
+	factory.instantMessageFactory = f
+}
+
+// Override
+func (factory *MessageGeneralFactory) GetInstantMessageFactory() InstantMessageFactory {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.instantMessageFactory
+}
+
+// Override
+func (factory *MessageGeneralFactory) CreateInstantMessage(head Envelope, body Content) InstantMessage {
+	f := factory.GetInstantMessageFactory()
+	return f.CreateInstantMessage(head, body)
+}
+
+// Override
+func (factory *MessageGeneralFactory) ParseInstantMessage(msg any) InstantMessage {
+	if ValueIsNil(msg) {
+		return nil
+	} else if iMsg, ok := msg.(InstantMessage); ok {
+		return iMsg
+	}
+	info := FetchMap(msg)
+	if info == nil {
+		//panic("instant message error")
+		return nil
+	}
+	f := factory.GetInstantMessageFactory()
+	return f.ParseInstantMessage(info)
+}
+
+// Override
+func (factory *MessageGeneralFactory) GenerateSerialNumber(msgType MessageType, now Time) SerialNumberType {
+	f := factory.GetInstantMessageFactory()
+	return f.GenerateSerialNumber(msgType, now)
+}
+
+//
+//  Secure Message
+//
+
+// Override
+func (factory *MessageGeneralFactory) SetSecureMessageFactory(f SecureMessageFactory) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	if factory.frozen {
+		// frozen, ignore it
+		return
+	}
+	factory.secureMessageFactory = f
+}
+
+// Override
+func (factory *MessageGeneralFactory) GetSecureMessageFactory() SecureMessageFactory {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.secureMessageFactory
+}
+
+// Override
+func (factory *MessageGeneralFactory) ParseSecureMessage(msg any) SecureMessage {
+	if ValueIsNil(msg) {
+		return nil
+	} else if sMsg, ok := msg.(SecureMessage); ok {
+		return sMsg
+	}
+	info := FetchMap(msg)
+	if info == nil {
+		//panic("secure message error")
+		return nil
+	}
+	f := factory.GetSecureMessageFactory()
+	return f.ParseSecureMessage(info)
+}
+
+//
+//  Reliable Message
+//
+
+// Override
+func (factory *MessageGeneralFactory) SetReliableMessageFactory(f ReliableMessageFactory) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	if factory.frozen {
+		// frozen, ignore it
+		return
+	}
+	factory.reliableMessageFactory = f
+}
+
+// Override
+func (factory *MessageGeneralFactory) GetReliableMessageFactory() ReliableMessageFactory {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.reliableMessageFactory
+}
+
+// Override
+func (factory *MessageGeneralFactory) ParseReliableMessage(msg any) ReliableMessage {
+	if ValueIsNil(msg) {
+		return nil
+	} else if rMsg, ok := msg.(ReliableMessage); ok {
+		return rMsg
+	}
+	info := FetchMap(msg)
+	if info == nil {
+		//panic("reliable message error")
+		return nil
+	}
+	f := factory.GetReliableMessageFactory()
+	return f.ParseReliableMessage(info)
+}
@@ -0,0 +1,149 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package plugins
+
+import (
+	. "github.com/dimchat/core-go/ext"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Content Parser
+ */
+
+// ContentParser wraps a constructor function as a ContentFactory
+type ContentParser struct {
+	//ContentFactory
+
+	fn func(dict StringKeyMap) Content
+}
+
+func NewContentParser(fn func(dict StringKeyMap) Content) *ContentParser {
+	return &ContentParser{
+		fn: fn,
+	}
+}
+
+// Override
+func (parser *ContentParser) ParseContent(content StringKeyMap) Content {
+	return parser.fn(content)
+}
+
+/**
+ *  Command Parser
+ */
+
+// CommandParser wraps a constructor function as a CommandFactory
+type CommandParser struct {
+	//CommandFactory
+
+	fn func(dict StringKeyMap) Command
+}
+
+func NewCommandParser(fn func(dict StringKeyMap) Command) *CommandParser {
+	return &CommandParser{
+		fn: fn,
+	}
+}
+
+// Override
+func (parser *CommandParser) ParseCommand(content StringKeyMap) Command {
+	return parser.fn(content)
+}
+
+/**
+ *  General Command Factory
+ */
+
+// GeneralCommandFactory parses command contents (ContentType.COMMAND/HISTORY)
+//
+// Dispatches the content to the command factory registered with its command name
+// in the context, if not found, parse it with the base command constructor
+type GeneralCommandFactory struct {
+	//ContentFactory
+	//CommandFactory
+
+	// context provides the command factories (nil means the default context)
+	context *Context
+
+	fn func(dict StringKeyMap) Command
+}
+
+func NewGeneralCommandFactory(fn func(dict StringKeyMap) Command) *GeneralCommandFactory {
+	return NewGeneralCommandFactoryWithContext(nil, fn)
+}
+
+func NewGeneralCommandFactoryWithContext(ctx *Context, fn func(dict StringKeyMap) Command) *GeneralCommandFactory {
+	return &GeneralCommandFactory{
+		context: ctx,
+		fn:      fn,
+	}
+}
+
+// Override
+func (factory *GeneralCommandFactory) ParseContent(content StringKeyMap) Content {
+	ctx := factory.context
+	var helper GeneralCommandHelper
+	if ctx == nil {
+		ctx = DefaultContext()
+		helper = GetGeneralCommandHelper()
+	} else {
+		helper, _ = ctx.GetCommandHelper().(GeneralCommandHelper)
+	}
+	// get factory by command name
+	var cmd string
+	if helper != nil {
+		cmd = helper.GetCMD(content, "")
+	} else {
+		cmd = GetCommandName(content, "")
+	}
+	var f CommandFactory
+	if cmd != "" {
+		f = ctx.GetCommandFactory(cmd)
+	}
+	if f == nil {
+		// check for group command
+		if _, exists := content["group"]; exists {
+			f = ctx.GetCommandFactory("group")
+		}
+		if f == nil {
+			f = factory
+		}
+	}
+	return f.ParseCommand(content)
+}
+
+// Override
+func (factory *GeneralCommandFactory) ParseCommand(content StringKeyMap) Command {
+	return factory.fn(content)
+}
@@ -0,0 +1,288 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// AppContent defines the interface for application-customized message contents
+//
+// Extends the base Content interface for messages intended for a specific application
+//
+//	Data structure: {
+//	    "type" : i2s(0xA0),
+//	    "sn"   : 123,
+//
+//	    "app"  : "{APP_ID}",  // application (e.g.: "chat.dim.sechat")
+//	    "extra": info         // others
+//	}
+type AppContent interface {
+	Content
+
+	// Application returns the target application ID (e.g., "chat.dim.sechat")
+	Application() string
+}
+
+// CustomizedContent defines the interface for customized message contents
+//
+// Extends AppContent for messages intended for a specific module + action,
+// so mini-apps & games could ride on DIM without claiming new content types
+//
+//	Data structure: {
+//	    "type"    : i2s(0xCC),   // or i2s(0xA0)
+//	    "sn"      : 123,
+//
+//	    "app"     : "{APP_ID}",  // application (e.g.: "chat.dim.sechat")
+//	    "mod"     : "{MODULE}",  // module name (e.g.: "drift_bottle")
+//	    "act"     : "{ACTION}",  // action name (e.g.: "throw")
+//	    "payload" : {...}        // action parameters
+//	}
+type CustomizedContent interface {
+	AppContent
+
+	// Module returns the target module name within the application (e.g., "drift_bottle")
+	Module() string
+
+	// Action returns the action name to execute in the module (e.g., "throw")
+	Action() string
+
+	// Payload returns the raw parameters for this action
+	Payload() any
+	SetPayload(payload any)
+}
+
+/**
+ *  Handler for CustomizedContent
+ */
+
+// CustomizedContentHandler processes the actions of one (app, mod)
+type CustomizedContentHandler interface {
+
+	// HandleAction processes the action from sender
+	//
+	// Parameters:
+	//   - act     - action name
+	//   - sender  - who sent this content
+	//   - content - customized content
+	//   - rMsg    - network message
+	//
+	// Returns: responses
+	HandleAction(act string, sender ID, content CustomizedContent, rMsg ReliableMessage) []Content
+}
+
+// CustomizedPayloadDecoder converts the raw payload of one (app, mod) to typed value
+type CustomizedPayloadDecoder interface {
+	DecodePayload(act string, payload any) (any, error)
+}
+
+// CustomizedPayloadDecoderFunc wraps a function as a CustomizedPayloadDecoder
+type CustomizedPayloadDecoderFunc func(act string, payload any) (any, error)
+
+// Override
+func (fn CustomizedPayloadDecoderFunc) DecodePayload(act string, payload any) (any, error) {
+	return fn(act, payload)
+}
+
+// ErrNoCustomizedHandler is returned when no handler registered for the (app, mod)
+var ErrNoCustomizedHandler = errors.New("customized content handler not found")
+
+// ErrNoCustomizedSender is returned when the sender of the content is unknown
+var ErrNoCustomizedSender = errors.New("customized content sender not found")
+
+// ANY_MODULE could be used to register a handler/decoder for all modules of an app
+const ANY_MODULE = "*"
+
+/**
+ *  Registry for CustomizedContent
+ */
+
+type customizedKey struct {
+	app string
+	mod string
+}
+
+// CustomizedRegistry keeps the handlers & payload decoders mapped by (app, mod)
+//
+// If nothing registered for the module, the one registered with
+// (app, ANY_MODULE) will be used.
+//
+// The maps are protected by a read-write lock;
+// after Freeze() is called, handlers/decoders set later will be ignored.
+type CustomizedRegistry struct {
+	mutex  sync.RWMutex
+	frozen bool
+
+	handlers map[customizedKey]CustomizedContentHandler
+	decoders map[customizedKey]CustomizedPayloadDecoder
+}
+
+func NewCustomizedRegistry() *CustomizedRegistry {
+	return &CustomizedRegistry{
+		handlers: make(map[customizedKey]CustomizedContentHandler, 16),
+		decoders: make(map[customizedKey]CustomizedPayloadDecoder, 16),
+	}
+}
+
+// Freeze stops accepting handlers & decoders,
+// any further registration will be ignored (the setters return false)
+func (registry *CustomizedRegistry) Freeze() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.frozen = true
+}
+
+func (registry *CustomizedRegistry) IsFrozen() bool {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return registry.frozen
+}
+
+//
+//  Handler
+//
+
+// SetHandler registers handler for (app, mod), nil to remove it
+//
+// Returns: false if the registry is frozen
+func (registry *CustomizedRegistry) SetHandler(app, mod string, handler CustomizedContentHandler) bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if registry.frozen {
+		return false
+	}
+	key := customizedKey{app: app, mod: mod}
+	if handler == nil {
+		delete(registry.handlers, key)
+	} else {
+		registry.handlers[key] = handler
+	}
+	return true
+}
+
+func (registry *CustomizedRegistry) GetHandler(app, mod string) CustomizedContentHandler {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	handler := registry.handlers[customizedKey{app: app, mod: mod}]
+	if handler == nil {
+		handler = registry.handlers[customizedKey{app: app, mod: ANY_MODULE}]
+	}
+	return handler
+}
+
+//
+//  Payload Decoder
+//
+
+// SetPayloadDecoder registers decoder for (app, mod), nil to remove it
+//
+// Returns: false if the registry is frozen
+func (registry *CustomizedRegistry) SetPayloadDecoder(app, mod string, decoder CustomizedPayloadDecoder) bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if registry.frozen {
+		return false
+	}
+	key := customizedKey{app: app, mod: mod}
+	if decoder == nil {
+		delete(registry.decoders, key)
+	} else {
+		registry.decoders[key] = decoder
+	}
+	return true
+}
+
+func (registry *CustomizedRegistry) GetPayloadDecoder(app, mod string) CustomizedPayloadDecoder {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	decoder := registry.decoders[customizedKey{app: app, mod: mod}]
+	if decoder == nil {
+		decoder = registry.decoders[customizedKey{app: app, mod: ANY_MODULE}]
+	}
+	return decoder
+}
+
+// DecodePayload converts the payload of content with the registered decoder,
+// if no decoder found, returns the raw payload
+func (registry *CustomizedRegistry) DecodePayload(content CustomizedContent) (any, error) {
+	payload := content.Payload()
+	decoder := registry.GetPayloadDecoder(content.Application(), content.Module())
+	if decoder == nil {
+		return payload, nil
+	}
+	return decoder.DecodePayload(content.Action(), payload)
+}
+
+// HandleContent dispatches the content to the handler registered for its (app, mod)
+//
+// Returns: ErrNoCustomizedHandler if no handler found,
+// ErrNoCustomizedSender if the message (or its sender) is missing
+func (registry *CustomizedRegistry) HandleContent(content CustomizedContent, rMsg ReliableMessage) ([]Content, error) {
+	app := content.Application()
+	mod := content.Module()
+	handler := registry.GetHandler(app, mod)
+	if handler == nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNoCustomizedHandler, app, mod)
+	}
+	var sender ID
+	if !ValueIsNil(rMsg) {
+		sender = rMsg.Sender()
+	}
+	if sender == nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNoCustomizedSender, app, mod)
+	}
+	return handler.HandleAction(content.Action(), sender, content, rMsg), nil
+}
+
+//
+//  Default registry
+//
+
+func GetCustomizedRegistry() *CustomizedRegistry {
+	ctx := DefaultContext()
+	return ctx.CustomizedRegistry()
+}
+
+func SetCustomizedHandler(app, mod string, handler CustomizedContentHandler) bool {
+	registry := GetCustomizedRegistry()
+	return registry.SetHandler(app, mod, handler)
+}
+
+func SetCustomizedPayloadDecoder(app, mod string, decoder CustomizedPayloadDecoder) bool {
+	registry := GetCustomizedRegistry()
+	return registry.SetPayloadDecoder(app, mod, decoder)
+}
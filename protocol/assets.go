@@ -31,8 +31,11 @@
 package protocol
 
 import (
+	"errors"
+
 	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
 )
 
 // MoneyContent defines the interface for monetary message content
@@ -80,3 +83,196 @@ type TransferContent interface {
 	Remittee() ID
 	SetRemittee(receiver ID)
 }
+
+// ClaimFulfillment is implemented by the transfer content
+// which pays for a ClaimPaymentContent
+//
+//	Data structure: {
+//	    "type"     : i2s(0x41),
+//	    "sn"       : 456,
+//	    ...
+//	    "claim"    : {         // claim for payment fulfilled
+//	        "sn" : 123
+//	    }
+//	}
+type ClaimFulfillment interface {
+
+	// ClaimSerialNumber returns the SN of the claim content
+	// which this transfer fulfills (0 means none)
+	ClaimSerialNumber() SerialNumberType
+	SetClaimSerialNumber(sn SerialNumberType)
+}
+
+// ClaimPaymentContent defines the interface for requesting money
+//
+// Extends MoneyContent with payee & invoice info, the payer should reply
+// a TransferContent (ClaimFulfillment) with 'claim.sn' refers to this content
+//
+//	Data structure: {
+//	    "type"     : i2s(0x48),
+//	    "sn"       : 123,
+//
+//	    "currency" : "RMB",        // USD, USDT, ...
+//	    "amount"   : 100.00,
+//	    "payee"    : "{TO}",       // who will receive the money
+//	    "memo"     : "dinner",
+//	    "due"      : 123,          // due date
+//	    "invoice"  : "{PNF}"       // invoice attachment (optional)
+//	}
+type ClaimPaymentContent interface {
+	MoneyContent
+
+	// Payee returns the entity ID to receive the money
+	Payee() ID
+	SetPayee(payee ID)
+
+	// Memo returns the reason for payment
+	Memo() string
+	SetMemo(memo string)
+
+	// DueDate returns the time before which the payment should be made
+	DueDate() Time
+	SetDueDate(when Time)
+
+	// Invoice returns the invoice attachment (PNF)
+	Invoice() TransportableFile
+	SetInvoice(invoice TransportableFile)
+}
+
+// Split modes for lucky money
+const (
+	LUCKY_MONEY_RANDOM = "random" // random amount for each share
+	LUCKY_MONEY_EQUAL  = "equal"  // same amount for each share
+)
+
+// LuckyMoneyContent defines the interface for red packet message contents
+//
+// Extends MoneyContent with share info, the total amount will be split
+// into 'count' shares and claimed by the group members
+//
+//	Data structure: {
+//	    "type"     : i2s(0x42),
+//	    "sn"       : 123,
+//
+//	    "currency" : "RMB",           // USD, USDT, ...
+//	    "amount"   : 100.00,          // total amount
+//	    "count"    : 10,              // share count
+//	    "mode"     : "random",        // split mode: "random", "equal"
+//	    "text"     : "Best wishes!",  // greeting text
+//	    "expires"  : 123,             // expiry time
+//
+//	    "claims"   : [                // claim results
+//	        {
+//	            "ID"     : "{MEMBER}",
+//	            "amount" : 12.34,
+//	            "time"   : 123
+//	        },
+//	        ...
+//	    ]
+//	}
+type LuckyMoneyContent interface {
+	MoneyContent
+
+	// Count returns the share count
+	Count() int
+
+	// Mode returns the split mode ("random" or "equal")
+	Mode() string
+
+	// Text returns the greeting text
+	Text() string
+
+	// Expires returns the expiry time, the rest will be refunded after that
+	Expires() Time
+	SetExpires(when Time)
+
+	// Claims returns the shares claimed (who received what)
+	Claims() []LuckyMoneyClaim
+
+	// AddClaim appends the claim result
+	//
+	// Returns: ErrClaimDuplicated if the member claimed before,
+	// ErrClaimsExhausted if all shares were claimed,
+	// ErrClaimOverdrawn if the total claimed exceeds the amount
+	AddClaim(claim LuckyMoneyClaim) error
+}
+
+// Reasons for rejecting a lucky money claim
+var (
+	ErrClaimDuplicated = errors.New("lucky money claimed already")
+	ErrClaimsExhausted = errors.New("lucky money shares exhausted")
+	ErrClaimOverdrawn  = errors.New("lucky money overdrawn")
+)
+
+// LuckyMoneyClaim records a share of lucky money received by a member
+//
+//	Data structure: {
+//	    "ID"     : "{MEMBER}",
+//	    "amount" : 12.34,
+//	    "time"   : 123
+//	}
+type LuckyMoneyClaim interface {
+	Mapper
+
+	// Receiver returns the member who claimed the share
+	Receiver() ID
+
+	// Amount returns the amount of the share
+	Amount() float64
+
+	// Time returns the claim time
+	Time() Time
+}
+
+// Settlement status of each member in split bill
+const (
+	SPLIT_BILL_UNPAID = "unpaid"
+	SPLIT_BILL_PAID   = "paid"
+)
+
+// SplitBillContent defines the interface for splitting a bill among members
+//
+// Extends MoneyContent ('amount' is the total), usually sent in a group
+// ('content.group'), the payer sends it again with updated status as
+// members pay their shares
+//
+//	Data structure: {
+//	    "type"     : i2s(0x49),
+//	    "sn"       : 123,
+//	    "group"    : "{GROUP_ID}",  // group context (optional)
+//
+//	    "currency" : "RMB",         // USD, USDT, ...
+//	    "amount"   : 100.00,        // total
+//	    "shares"   : {
+//	        "{MEMBER}" : {
+//	            "amount"   : 25.00,
+//	            "status"   : "paid",  // "unpaid", "paid"
+//	            "transfer" : 123      // SN of the transfer content (optional)
+//	        },
+//	        ...
+//	    }
+//	}
+type SplitBillContent interface {
+	MoneyContent
+
+	// Members returns the members who share the bill
+	Members() []ID
+
+	// Share returns the amount the member should pay
+	Share(member ID) float64
+	SetShare(member ID, amount float64)
+
+	// Status returns the settlement status of the member
+	Status(member ID) string
+	SetStatus(member ID, status string)
+
+	// MarkPaid updates the member's status to "paid"
+	//
+	// Parameters:
+	//   - member : member who paid
+	//   - sn     : SN of the transfer content (0 means unknown)
+	MarkPaid(member ID, sn SerialNumberType)
+
+	// IsSettled checks whether all members paid
+	IsSettled() bool
+}
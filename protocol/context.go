@@ -0,0 +1,253 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package protocol
+
+import (
+	"sync"
+	"sync/atomic"
+
+	. "github.com/dimchat/core-go/format"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Protocol Context
+ */
+
+// Context carries the helpers for parsing & creating protocol objects
+//
+// The package-level functions (ParseCommand, PurifyForQuote, ...) and
+// the format functions (GetDataCoder, CreateTransportableFileWrapper, ...)
+// work with the default context; a process hosting several configurations
+// can create its own contexts and pass them explicitly.
+//
+// The helpers are protected by a read-write lock;
+// after Freeze() is called, they cannot be replaced anymore.
+type Context struct {
+	mutex  sync.RWMutex
+	frozen bool
+
+	// data coders & PNF wrapper factory
+	*FormatContext
+
+	commandHelper CommandHelper
+	quoteHelper   QuoteHelper
+
+	// content factories for this context (nil means the process-wide helper)
+	contentHelper ContentHelper
+
+	// handlers & decoders for customized contents
+	customizedRegistry *CustomizedRegistry
+}
+
+// NewContext creates a context with default helpers,
+// if formatContext is nil, a new one will be created
+func NewContext(formatContext *FormatContext) *Context {
+	if formatContext == nil {
+		formatContext = NewFormatContext()
+	}
+	return &Context{
+		FormatContext: formatContext,
+		commandHelper: NewCommandGeneralFactory(),
+		quoteHelper:   &QuotePurifier{},
+
+		customizedRegistry: NewCustomizedRegistry(),
+	}
+}
+
+var sharedContext atomic.Value // *Context
+
+// SetDefaultContext replaces the default context (nil is ignored)
+func SetDefaultContext(ctx *Context) {
+	if ctx == nil {
+		return
+	}
+	sharedContext.Store(ctx)
+	SetFormatContext(ctx.FormatContext)
+}
+
+func DefaultContext() *Context {
+	return sharedContext.Load().(*Context)
+}
+
+func init() {
+	sharedContext.Store(NewContext(GetFormatContext()))
+}
+
+// Freeze stops replacing helpers & registering factories,
+// call it after all extensions are loaded.
+//
+// Setters called after that will NOT panic, the new value is just ignored
+// (and the setter returns false), so parsing on other goroutines is never
+// disturbed.
+func (ctx *Context) Freeze() {
+	ctx.mutex.Lock()
+	ctx.frozen = true
+	helper := ctx.commandHelper
+	contentHelper := ctx.contentHelper
+	ctx.mutex.Unlock()
+	// freeze registries
+	ctx.FormatContext.Freeze()
+	if registry, ok := helper.(interface{ Freeze() }); ok {
+		registry.Freeze()
+	}
+	if registry, ok := contentHelper.(interface{ Freeze() }); ok {
+		registry.Freeze()
+	}
+	ctx.customizedRegistry.Freeze()
+}
+
+func (ctx *Context) IsFrozen() bool {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+	return ctx.frozen
+}
+
+//
+//  Command
+//
+
+func (ctx *Context) SetCommandHelper(helper CommandHelper) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	if ctx.frozen {
+		return false
+	}
+	ctx.commandHelper = helper
+	return true
+}
+
+func (ctx *Context) GetCommandHelper() CommandHelper {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+	return ctx.commandHelper
+}
+
+func (ctx *Context) ParseCommand(content any) Command {
+	helper := ctx.GetCommandHelper()
+	return helper.ParseCommand(content)
+}
+
+func (ctx *Context) GetCommandFactory(cmd string) CommandFactory {
+	helper := ctx.GetCommandHelper()
+	return helper.GetCommandFactory(cmd)
+}
+
+func (ctx *Context) SetCommandFactory(cmd string, factory CommandFactory) {
+	helper := ctx.GetCommandHelper()
+	helper.SetCommandFactory(cmd, factory)
+}
+
+//
+//  Content
+//
+
+// SetContentHelper sets the content factories for this context,
+// so the commands could be parsed with the command factories of this context
+func (ctx *Context) SetContentHelper(helper ContentHelper) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	if ctx.frozen {
+		return false
+	}
+	ctx.contentHelper = helper
+	return true
+}
+
+// GetContentHelper returns the content helper of this context,
+// or the process-wide one if not set
+func (ctx *Context) GetContentHelper() ContentHelper {
+	ctx.mutex.RLock()
+	helper := ctx.contentHelper
+	ctx.mutex.RUnlock()
+	if helper == nil {
+		helper = GetContentHelper()
+	}
+	return helper
+}
+
+func (ctx *Context) ParseContent(content any) Content {
+	helper := ctx.GetContentHelper()
+	return helper.ParseContent(content)
+}
+
+// ContentConvert parses a list of contents with this context
+func (ctx *Context) ContentConvert(array any) []Content {
+	values := FetchList(array)
+	contents := make([]Content, 0, len(values))
+	for _, item := range values {
+		content := ctx.ParseContent(item)
+		if content == nil {
+			continue
+		}
+		contents = append(contents, content)
+	}
+	return contents
+}
+
+//
+//  Quote
+//
+
+func (ctx *Context) SetQuoteHelper(helper QuoteHelper) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	if ctx.frozen {
+		return false
+	}
+	ctx.quoteHelper = helper
+	return true
+}
+
+func (ctx *Context) GetQuoteHelper() QuoteHelper {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+	return ctx.quoteHelper
+}
+
+func (ctx *Context) PurifyForQuote(head Envelope, body Content) StringKeyMap {
+	helper := ctx.GetQuoteHelper()
+	return helper.PurifyForQuote(head, body)
+}
+
+func (ctx *Context) PurifyForReceipt(head Envelope, body Content) StringKeyMap {
+	helper := ctx.GetQuoteHelper()
+	return helper.PurifyForReceipt(head, body)
+}
+
+//
+//  Customized
+//
+
+func (ctx *Context) CustomizedRegistry() *CustomizedRegistry {
+	return ctx.customizedRegistry
+}
@@ -0,0 +1,149 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package protocol
+
+import (
+	"sync"
+
+	. "github.com/dimchat/mkm-go/types"
+)
+
+/**
+ *  Command GeneralFactory
+ */
+
+// CommandGeneralFactory is the default implementation of CommandHelper
+//
+// Keeps the command factories mapped by command name,
+// and also provides the command name for GeneralCommandHelper.
+// If no factory found for the command name, the factory registered
+// with "*" will be used as the base command factory.
+//
+// The factory map is protected by a read-write lock;
+// after Freeze() is called, factories set later will be ignored.
+type CommandGeneralFactory struct {
+	//CommandHelper, GeneralCommandHelper
+
+	mutex  sync.RWMutex
+	frozen bool
+
+	commandFactories map[string]CommandFactory
+}
+
+func NewCommandGeneralFactory() *CommandGeneralFactory {
+	return &CommandGeneralFactory{
+		commandFactories: make(map[string]CommandFactory, 32),
+	}
+}
+
+// Freeze stops accepting command factories,
+// any further registration will be ignored (check it with IsFrozen())
+func (factory *CommandGeneralFactory) Freeze() {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	factory.frozen = true
+}
+
+func (factory *CommandGeneralFactory) IsFrozen() bool {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.frozen
+}
+
+//
+//  CMD
+//
+
+// Override
+func (factory *CommandGeneralFactory) GetCMD(content StringKeyMap, defaultValue string) string {
+	return GetCommandName(content, defaultValue)
+}
+
+// GetCommandName returns the value of "command" (or "cmd" for v1.0)
+func GetCommandName(content StringKeyMap, defaultValue string) string {
+	cmd, exists := content["command"]
+	if !exists {
+		// compatible with v1.0
+		cmd, exists = content["cmd"]
+		if !exists {
+			return defaultValue
+		}
+	}
+	return ConvertString(cmd, defaultValue)
+}
+
+//
+//  Command
+//
+
+// Override
+func (factory *CommandGeneralFactory) SetCommandFactory(cmd string, f CommandFactory) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+	if factory.frozen {
+		// frozen, ignore it
+		return
+	}
+	factory.commandFactories[cmd] = f
+}
+
+// Override
+func (factory *CommandGeneralFactory) GetCommandFactory(cmd string) CommandFactory {
+	factory.mutex.RLock()
+	defer factory.mutex.RUnlock()
+	return factory.commandFactories[cmd]
+}
+
+// Override
+func (factory *CommandGeneralFactory) ParseCommand(content any) Command {
+	if ValueIsNil(content) {
+		return nil
+	} else if command, ok := content.(Command); ok {
+		return command
+	}
+	info := FetchMap(content)
+	if info == nil {
+		//panic("command error")
+		return nil
+	}
+	// get factory by command name
+	cmd := factory.GetCMD(info, "")
+	f := factory.GetCommandFactory(cmd)
+	if f == nil {
+		// unknown command name, get base command factory
+		f = factory.GetCommandFactory("*")
+		if f == nil {
+			//panic("default command factory not found")
+			return nil
+		}
+	}
+	return f.ParseCommand(info)
+}
@@ -30,7 +30,10 @@
  */
 package protocol
 
-import . "github.com/dimchat/mkm-go/protocol"
+import (
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+)
 
 // History command name constants for account operations
 // These values are used as the "command" field in HistoryCommand messages
@@ -58,6 +61,72 @@ type HistoryCommand interface {
 	Event() string
 }
 
+// RegisterCommand defines the interface for account registration commands
+//
+// Extends HistoryCommand for the "register" operation, carrying the meta
+// and the initial visa of the new account
+//
+//	Data structure: {
+//	    "type"    : i2s(0x89),
+//	    "sn"      : 123,
+//
+//	    "command" : "register",  // Fixed command name: "register"
+//	    "time"    : 123.456,     // Timestamp of the registration
+//
+//	    "did"     : "{ID}",      // New account ID
+//	    "meta"    : {...},       // Account meta
+//	    "visa"    : {...}        // Initial visa (signed by the meta key)
+//	}
+type RegisterCommand interface {
+	HistoryCommand
+
+	// ID returns the new account ID
+	ID() ID
+
+	// Meta returns the meta generated the account ID
+	Meta() Meta
+
+	// Visa returns the initial visa document
+	Visa() Visa
+}
+
+// SuicideCommand defines the interface for account deletion commands
+//
+// Extends HistoryCommand for the "suicide" operation, the farewell must be
+// signed by the account meta key
+//
+//	Data structure: {
+//	    "type"      : i2s(0x89),
+//	    "sn"        : 123,
+//
+//	    "command"   : "suicide",         // Fixed command name: "suicide"
+//	    "time"      : 123.456,           // Timestamp of the deletion
+//
+//	    "did"       : "{ID}",            // Account ID to be removed
+//	    "farewell"  : "Goodbye world!",  // Last words
+//	    "successor" : "{ID}",            // New account to follow (optional)
+//	    "signature" : "{BASE64}"         // sign(farewell data, SK)
+//	}
+type SuicideCommand interface {
+	HistoryCommand
+
+	// ID returns the account ID to be removed
+	ID() ID
+
+	// Farewell returns the last words of the account
+	Farewell() string
+
+	// Successor returns the new account ID to follow (nil if none)
+	Successor() ID
+
+	// Signature returns the signature for the farewell data
+	Signature() TransportableData
+	SetSignature(signature TransportableData)
+
+	// SignData builds the data to be signed with the account meta key
+	SignData() []byte
+}
+
 // Group command name constants for group membership/role operations
 // These values are used as the "command" field in GroupCommand messages
 const (
@@ -173,3 +242,119 @@ type QuitCommand interface {
 type ResetCommand interface {
 	GroupCommand
 }
+
+// FoundCommand defines the interface for group creation commands
+//
+// Extends GroupCommand for the "found" group operation (sent by the founder)
+//
+//	Data structure: {
+//	    "type"    : i2s(0x89),
+//	    "sn"      : 123,
+//
+//	    "command" : "found",       // Fixed command name: "found"
+//	    "time"    : 123.456,       // Timestamp when the group founded
+//
+//	    "group"   : "{GROUP_ID}",  // New group ID
+//	    "members" : ["{MEMBER_ID}",]  // Initial members (founder first)
+//	}
+type FoundCommand interface {
+	GroupCommand
+}
+
+// AbdicateCommand defines the interface for group ownership transfer commands
+//
+// Extends GroupCommand for the "abdicate" group operation (sent by the owner)
+//
+//	Data structure: {
+//	    "type"    : i2s(0x89),
+//	    "sn"      : 123,
+//
+//	    "command" : "abdicate",    // Fixed command name: "abdicate"
+//	    "time"    : 123.456,       // Timestamp of the transfer
+//
+//	    "group"   : "{GROUP_ID}",  // Target group ID
+//	    "owner"   : "{NEW_OWNER}"  // Member ID to be the new owner
+//	}
+type AbdicateCommand interface {
+	GroupCommand
+
+	// NewOwner returns the member ID who takes over the group
+	NewOwner() ID
+	SetNewOwner(owner ID)
+}
+
+// HireCommand defines the interface for group administrator appointment commands
+//
+// Extends GroupCommand for the "hire" group operation (sent by the owner)
+//
+//	Data structure: {
+//	    "type"           : i2s(0x89),
+//	    "sn"             : 123,
+//
+//	    "command"        : "hire",        // Fixed command name: "hire"
+//	    "time"           : 123.456,       // Timestamp of the appointment
+//
+//	    "group"          : "{GROUP_ID}",  // Target group ID
+//	    "administrators" : ["{ADMIN_ID}",],
+//	    "assistants"     : ["{BOT_ID}",]  // Optional group bots
+//	}
+type HireCommand interface {
+	GroupCommand
+
+	// Administrators returns the member IDs to be administrators
+	Administrators() []ID
+	SetAdministrators(admins []ID)
+
+	// Assistants returns the bot IDs to be assistants
+	Assistants() []ID
+	SetAssistants(bots []ID)
+}
+
+// FireCommand defines the interface for group administrator removal commands
+//
+// Extends GroupCommand for the "fire" group operation (sent by the owner)
+//
+//	Data structure: {
+//	    "type"           : i2s(0x89),
+//	    "sn"             : 123,
+//
+//	    "command"        : "fire",        // Fixed command name: "fire"
+//	    "time"           : 123.456,       // Timestamp of the removal
+//
+//	    "group"          : "{GROUP_ID}",  // Target group ID
+//	    "administrators" : ["{ADMIN_ID}",],
+//	    "assistants"     : ["{BOT_ID}",]  // Optional group bots
+//	}
+type FireCommand interface {
+	GroupCommand
+
+	// Administrators returns the administrator IDs to be removed
+	Administrators() []ID
+	SetAdministrators(admins []ID)
+
+	// Assistants returns the bot IDs to be removed
+	Assistants() []ID
+	SetAssistants(bots []ID)
+}
+
+// ResignCommand defines the interface for administrator resignation commands
+//
+// Extends GroupCommand for the "resign" group operation (sent by the administrator)
+//
+//	Data structure: {
+//	    "type"           : i2s(0x89),
+//	    "sn"             : 123,
+//
+//	    "command"        : "resign",      // Fixed command name: "resign"
+//	    "time"           : 123.456,       // Timestamp of the resignation
+//
+//	    "group"          : "{GROUP_ID}",  // Target group ID
+//	    "administrators" : ["{ADMIN_ID}",]  // Administrators stepping down (the sender)
+//	}
+type ResignCommand interface {
+	GroupCommand
+
+	// Administrators returns the administrator IDs stepping down
+	Administrators() []ID
+	SetAdministrators(admins []ID)
+}
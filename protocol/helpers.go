@@ -46,14 +46,14 @@ type CommandHelper interface {
 	ParseCommand(content any) Command
 }
 
-var sharedCommandHelper CommandHelper = nil
-
 func SetCommandHelper(helper CommandHelper) {
-	sharedCommandHelper = helper
+	ctx := DefaultContext()
+	ctx.SetCommandHelper(helper)
 }
 
 func GetCommandHelper() CommandHelper {
-	return sharedCommandHelper
+	ctx := DefaultContext()
+	return ctx.GetCommandHelper()
 }
 
 /**
@@ -65,14 +65,14 @@ type QuoteHelper interface {
 	PurifyForReceipt(head Envelope, body Content) StringKeyMap
 }
 
-var sharedQuoteHelper QuoteHelper = &QuotePurifier{}
-
 func SetQuoteHelper(helper QuoteHelper) {
-	sharedQuoteHelper = helper
+	ctx := DefaultContext()
+	ctx.SetQuoteHelper(helper)
 }
 
 func GetQuoteHelper() QuoteHelper {
-	return sharedQuoteHelper
+	ctx := DefaultContext()
+	return ctx.GetQuoteHelper()
 }
 
 type QuotePurifier struct {
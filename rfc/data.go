@@ -27,6 +27,7 @@ package rfc
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	. "github.com/dimchat/mkm-go/types"
@@ -132,7 +133,9 @@ func (header *BaseHeader) ExtraKeys() []string {
 	if extra == nil {
 		return nil
 	}
-	return MapKeys(extra)
+	keys := MapKeys(extra)
+	sort.Strings(keys)
+	return keys
 }
 
 // Override
@@ -143,7 +146,6 @@ func (header *BaseHeader) ExtraValue(name string) string {
 		return ""
 	} else if name == "" {
 		panic("header name should not be empty")
-		return ""
 	}
 	name = strings.ToLower(name)
 	value, exists := extra[name]
@@ -177,10 +179,8 @@ func (header *BaseHeader) String() string {
 		//
 		//  2. extra info: 'charset' & 'filename'
 		//
-		if extra != nil {
-			for key, value := range extra {
-				items = append(items, fmt.Sprintf("%s=%s", key, value))
-			}
+		for _, key := range header.ExtraKeys() {
+			items = append(items, fmt.Sprintf("%s=%s", key, extra[key]))
 		}
 		//
 		//  3. 'encoding'